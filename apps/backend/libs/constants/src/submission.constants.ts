@@ -45,6 +45,8 @@ export const Status = (code: number) => {
       return ResultStatus.SegmentationFaultError
     case 10: // Canceled
       return ResultStatus.Canceled
+    case 11: // OUTPUT_LIMIT_EXCEEDED
+      return ResultStatus.OutputLimitExceeded
     default:
       return ResultStatus.ServerError
   }
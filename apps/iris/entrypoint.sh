@@ -33,9 +33,10 @@ echo "export CONTAINER_ID=$CONTAINER_ID" >> ~/.bashrc
 export CONTAINER_ID=$CONTAINER_ID
 source ~/.bashrc
 
+# exec으로 iris가 SIGTERM을 직접 받아 진행 중인 채점을 drain할 수 있도록 함
 if [[ -n "$APP_ENV" && $APP_ENV = "production" ]]
     then ECS_CONTAINER_ID=$(head -1 /proc/self/cgroup | cut -d/ -f4)
-    ./iris $ECS_CONTAINER_ID
+    exec ./iris $ECS_CONTAINER_ID
 else
-    ./iris
+    exec ./iris
 fi
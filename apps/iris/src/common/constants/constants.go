@@ -55,6 +55,7 @@ const (
 )
 
 const MAX_OUTPUT = 1048576 // 1MB
+const MAX_STDERR = 65536   // 64KB
 
 type MessageType string
 
@@ -68,5 +69,6 @@ const (
 	Generate     MessageType = "generate"
 	Validate     MessageType = "validate"
 	Check        MessageType = "check"
+	Cancel       MessageType = "cancel"
 	Default      MessageType = Judge
 )
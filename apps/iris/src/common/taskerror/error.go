@@ -24,6 +24,7 @@ const (
 	SEGMENTATION_FAULT_ERROR
 	SERVER_ERROR
 	CANCELED
+	OUTPUT_LIMIT_EXCEEDED
 )
 
 type Error struct {
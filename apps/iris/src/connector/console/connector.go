@@ -0,0 +1,39 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/skkuding/codedang/apps/iris/src/connector/stream"
+	"github.com/skkuding/codedang/apps/iris/src/router"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+)
+
+type connector struct {
+	in     io.Reader
+	out    io.Writer
+	router router.Router
+	logger logger.Logger
+}
+
+// NewConnector reads stream.Message lines from stdin and writes stream.Result
+// lines to stdout until stdin is closed. Logs go to stderr, so stdout can be
+// piped.
+func NewConnector(
+	router router.Router,
+	logger logger.Logger,
+) *connector {
+	return &connector{os.Stdin, os.Stdout, router, logger}
+}
+
+func (c *connector) Connect(ctx context.Context) {
+	if err := stream.Process(ctx, c.in, c.out, c.router, c.logger); err != nil {
+		c.logger.Log(logger.ERROR, fmt.Sprintf("failed to process stdin: %s", err))
+		return
+	}
+	c.logger.Log(logger.DEBUG, "connector done")
+}
+
+func (c *connector) Disconnect() {}
@@ -3,6 +3,9 @@ package connector
 import (
 	"fmt"
 
+	"github.com/skkuding/codedang/apps/iris/src/connector/console"
+	"github.com/skkuding/codedang/apps/iris/src/connector/file"
+	"github.com/skkuding/codedang/apps/iris/src/connector/http"
 	"github.com/skkuding/codedang/apps/iris/src/connector/rabbitmq"
 	"github.com/skkuding/codedang/apps/iris/src/router"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
@@ -45,11 +48,19 @@ func Factory(c Module, p Providers, args ...any) Connector {
 
 		return rabbitmq.NewConnector(consumer, producer, p.Router, p.Logger)
 	case HTTP:
-		p.Logger.Panic("HTTP connector need to be implemented")
+		config, ok := args[0].(http.ConnectorConfig)
+		if !ok {
+			p.Logger.Panic(fmt.Sprintf("Invalid http connector config: %v", args[0]))
+		}
+		return http.NewConnector(config, p.Router, p.Logger)
 	case FILE:
-		p.Logger.Panic("File connector need to be implemented")
+		config, ok := args[0].(file.ConnectorConfig)
+		if !ok {
+			p.Logger.Panic(fmt.Sprintf("Invalid file connector config: %v", args[0]))
+		}
+		return file.NewConnector(config, p.Router, p.Logger)
 	case CONSOLE:
-		p.Logger.Panic("Console connector need to be implemented")
+		return console.NewConnector(p.Router, p.Logger)
 	default:
 		p.Logger.Panic(fmt.Sprintf("Invalid connector type: %s", c))
 	}
@@ -0,0 +1,89 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/skkuding/codedang/apps/iris/src/connector/stream"
+	"github.com/skkuding/codedang/apps/iris/src/router"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+)
+
+type ConnectorConfig struct {
+	// InputPath is a JSONL file of stream.Message, or a directory whose
+	// *.jsonl files are read in lexical order.
+	InputPath string
+	// OutputPath is the JSONL file stream.Result lines are written to.
+	OutputPath string
+}
+
+type connector struct {
+	config ConnectorConfig
+	router router.Router
+	logger logger.Logger
+}
+
+// NewConnector judges archived messages offline. Connect returns once every
+// input file has been processed.
+func NewConnector(
+	config ConnectorConfig,
+	router router.Router,
+	logger logger.Logger,
+) *connector {
+	return &connector{config, router, logger}
+}
+
+func (c *connector) Connect(ctx context.Context) {
+	inputs, err := inputFiles(c.config.InputPath)
+	if err != nil {
+		c.logger.Panic(fmt.Sprintf("failed to list input files: %s", err))
+		return
+	}
+
+	out, err := os.Create(c.config.OutputPath)
+	if err != nil {
+		c.logger.Panic(fmt.Sprintf("failed to create output file: %s", err))
+		return
+	}
+	defer out.Close()
+
+	for _, input := range inputs {
+		c.logger.Log(logger.INFO, fmt.Sprintf("processing %s", input))
+		if err := c.process(ctx, input, out); err != nil {
+			c.logger.Log(logger.ERROR, fmt.Sprintf("failed to process %s: %s", input, err))
+			return
+		}
+	}
+	c.logger.Log(logger.DEBUG, "connector done")
+}
+
+func (c *connector) Disconnect() {}
+
+func (c *connector) process(ctx context.Context, input string, out *os.File) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return stream.Process(ctx, in, out, c.router, c.logger)
+}
+
+func inputFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
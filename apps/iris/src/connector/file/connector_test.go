@@ -0,0 +1,34 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.jsonl", "a.jsonl", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	t.Run("lists jsonl files of a directory in order", func(t *testing.T) {
+		files, err := inputFiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "a.jsonl"), filepath.Join(dir, "b.jsonl")}, files)
+	})
+
+	t.Run("accepts a single file", func(t *testing.T) {
+		files, err := inputFiles(filepath.Join(dir, "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "notes.txt")}, files)
+	})
+
+	t.Run("fails on missing path", func(t *testing.T) {
+		_, err := inputFiles(filepath.Join(dir, "missing"))
+		assert.Error(t, err)
+	})
+}
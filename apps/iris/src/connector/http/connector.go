@@ -1,16 +1,230 @@
 package http
 
 import (
-	"github.com/skkuding/codedang/iris/src/service/logger"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	instrumentation "github.com/skkuding/codedang/apps/iris/src"
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/router"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MessageIdHeader plays the role of the AMQP message_id property. A random
+	// id is used if the client does not send one.
+	MessageIdHeader = "X-Message-Id"
+	messageIdLen    = 16
+
+	maxBodySize = 64 * 1024 * 1024
 )
 
+type ConnectorConfig struct {
+	Addr string
+	// Token is required as "Authorization: Bearer <token>" when set
+	Token string
+	// ShutdownTimeout bounds how long requests being handled may take to
+	// finish once the connector stops. They are canceled afterwards.
+	ShutdownTimeout time.Duration
+}
+
+// envelope wraps one response.Response of a chunked JSON stream. Responses
+// are written one per line (NDJSON).
+type envelope struct {
+	Type    constants.MessageType `json:"type"`
+	Message json.RawMessage       `json:"message"`
+}
+
 type connector struct {
-	done   chan error
-	logger logger.Logger
+	server *http.Server
+	// cancelRequests cancels the requests still being handled once the
+	// server failed to shut down in time.
+	cancelRequests  context.CancelFunc
+	inFlight        sync.WaitGroup
+	shutdownTimeout time.Duration
+	authenticated   bool
+	router          router.Router
+	logger          logger.Logger
 }
 
+// NewConnector serves POST /{type}, where type is a message type such as
+// judge or validate and the body is the message as it would be published to
+// the queue. Responses are streamed back as they are produced, as Server-Sent
+// Events if the client accepts text/event-stream and as chunked NDJSON
+// otherwise.
 func NewConnector(
+	config ConnectorConfig,
+	router router.Router,
 	logger logger.Logger,
 ) *connector {
-	return &connector{make(chan error), logger}
+	c := &connector{shutdownTimeout: config.ShutdownTimeout, router: router, logger: logger}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /{type}", c.handle)
+	var handler http.Handler = mux
+	if config.Token != "" {
+		handler = requireToken(config.Token, mux)
+	}
+	c.authenticated = config.Token != ""
+	base, cancel := context.WithCancel(context.Background())
+	c.cancelRequests = cancel
+	c.server = &http.Server{
+		Addr:        config.Addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return base },
+	}
+	return c
+}
+
+func (c *connector) Connect(ctx context.Context) {
+	shutdown := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		c.Disconnect()
+		close(shutdown)
+	}()
+
+	c.logger.Log(logger.INFO, fmt.Sprintf("http connector listening on %s", c.server.Addr))
+	if !c.authenticated {
+		c.logger.Log(logger.WARN, "http connector accepts requests without a token")
+	}
+	err := c.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.logger.Panic(fmt.Sprintf("failed to serve: %s", err))
+	}
+	// ListenAndServe는 Shutdown 직후 반환되므로 진행 중인 요청이 끝날 때까지 대기
+	if ctx.Err() != nil {
+		<-shutdown
+	}
+	c.logger.Log(logger.DEBUG, "connector done")
+}
+
+// Disconnect stops accepting requests and waits for the ones being handled.
+// Those still running after the shutdown timeout are canceled, and
+// Disconnect returns once they gave up.
+func (c *connector) Disconnect() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		c.logger.Log(logger.ERROR, fmt.Sprintf("failed to shut down http connector: %s", err))
+		c.cancelRequests()
+	}
+	c.inFlight.Wait()
+}
+
+func (c *connector) handle(w http.ResponseWriter, r *http.Request) {
+	c.inFlight.Add(1)
+	defer c.inFlight.Done()
+
+	extractedCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	span := trace.SpanFromContext(extractedCtx)
+	tracer := otel.Tracer("Connector")
+	spanCtx, childSpan := tracer.Start(
+		extractedCtx,
+		instrumentation.GetSemanticSpanName("connector", "handle"),
+		trace.WithLinks(trace.Link{SpanContext: span.SpanContext()}),
+		trace.WithSpanKind(trace.SpanKindServer),
+	)
+	defer childSpan.End()
+
+	messageType := constants.MessageType(r.PathValue("type"))
+	messageId := r.Header.Get(MessageIdHeader)
+	if messageId == "" {
+		messageId = utils.RandString(messageIdLen)
+	}
+
+	body, err := readBody(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+
+	resultChan := make(chan response.Response, 1)
+	go func() {
+		defer close(resultChan)
+		c.router.Route(messageType, messageId, body, resultChan, spanCtx)
+	}()
+
+	// 첫 응답이 오기 전까지 헤더를 미뤄, 라우터가 거부한 타입은 404로 응답
+	written := false
+	for result := range resultChan {
+		if !written {
+			if sse {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+			} else {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+			}
+			w.Header().Set(MessageIdHeader, messageId)
+			w.WriteHeader(http.StatusOK)
+			written = true
+		}
+
+		if err := writeResponse(w, result, sse); err != nil {
+			c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to write result: %s: %s", string(result.Message), err), spanCtx)
+			continue
+		}
+		flusher.Flush()
+	}
+
+	if !written {
+		http.Error(w, fmt.Sprintf("unsupported message type: %s", messageType), http.StatusNotFound)
+	}
+}
+
+// requireToken rejects requests that do not carry token as a bearer token,
+// since every request runs code in the sandbox.
+func requireToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	var body json.RawMessage
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := decoder.Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	return body, nil
+}
+
+func writeResponse(w http.ResponseWriter, result response.Response, sse bool) error {
+	data, err := json.Marshal(envelope{Type: result.Type, Message: result.Message})
+	if err != nil {
+		return err
+	}
+	if sse {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", result.Type, data)
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+	}
+	return err
 }
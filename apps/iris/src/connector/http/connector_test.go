@@ -0,0 +1,219 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+// echoRouter answers every judge message with one response per testcase and
+// a submission response, and ignores other types like the real router does
+// for unsupported ones.
+type echoRouter struct {
+	id   string
+	body []byte
+}
+
+func (r *echoRouter) Route(path constants.MessageType, id string, data []byte, out chan<- response.Response, ctx context.Context) {
+	r.id, r.body = id, data
+	if path != constants.Judge {
+		return
+	}
+	out <- response.Response{Message: []byte(`{"testcaseId":1}` + "\n"), Type: constants.Judge}
+	out <- response.Response{Message: []byte(`{"testcaseId":2}` + "\n"), Type: constants.Judge}
+	out <- response.Response{Message: []byte(`{"judgeResult":[]}` + "\n"), Type: constants.Submission}
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *echoRouter) {
+	return newTestServerWithConfig(t, ConnectorConfig{})
+}
+
+func newTestServerWithConfig(t *testing.T, config ConnectorConfig) (*httptest.Server, *echoRouter) {
+	router := &echoRouter{}
+	c := NewConnector(config, router, noopLogger{})
+	server := httptest.NewServer(c.server.Handler)
+	t.Cleanup(server.Close)
+	return server, router
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("streams NDJSON", func(t *testing.T) {
+		server, router := newTestServer(t)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/judge", strings.NewReader(`{"problemId": 1}`))
+		require.NoError(t, err)
+		req.Header.Set(MessageIdHeader, "42")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+		assert.Equal(t, "42", router.id)
+		assert.JSONEq(t, `{"problemId": 1}`, string(router.body))
+
+		var got []envelope
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			var e envelope
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+			got = append(got, e)
+		}
+		require.Len(t, got, 3)
+		assert.Equal(t, constants.Judge, got[0].Type)
+		assert.JSONEq(t, `{"testcaseId":2}`, string(got[1].Message))
+		assert.Equal(t, constants.Submission, got[2].Type)
+	})
+
+	t.Run("streams server-sent events", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/judge", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/event-stream")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		assert.NotEmpty(t, res.Header.Get(MessageIdHeader))
+
+		var events []string
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if event, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				events = append(events, event)
+			}
+		}
+		assert.Equal(t, []string{"judge", "judge", "submission"}, events)
+	})
+
+	t.Run("rejects unsupported types", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		res, err := http.Post(server.URL+"/unknown", "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("rejects invalid bodies", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		res, err := http.Post(server.URL+"/judge", "application/json", strings.NewReader(`{`))
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("accepts only POST", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		res, err := http.Get(server.URL + "/judge")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	})
+}
+
+func TestRequireToken(t *testing.T) {
+	server, router := newTestServerWithConfig(t, ConnectorConfig{Token: "secret"})
+
+	post := func(t *testing.T, authorization string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/judge", strings.NewReader(`{"problemId": 1}`))
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	t.Run("rejects requests without the token", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+			res := post(t, authorization)
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode, authorization)
+			assert.Equal(t, "Bearer", res.Header.Get("WWW-Authenticate"))
+		}
+		assert.Nil(t, router.body)
+	})
+
+	t.Run("accepts requests with the token", func(t *testing.T) {
+		res := post(t, "Bearer secret")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+// blockingRouter answers nothing until ctx is done, and takes a while to
+// give up like a task cleaning up after itself.
+type blockingRouter struct {
+	started  chan struct{}
+	finished atomic.Bool
+}
+
+func (r *blockingRouter) Route(_ constants.MessageType, _ string, _ []byte, _ chan<- response.Response, ctx context.Context) {
+	close(r.started)
+	<-ctx.Done()
+	time.Sleep(20 * time.Millisecond)
+	r.finished.Store(true)
+}
+
+func TestConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	router := &blockingRouter{started: make(chan struct{})}
+	c := NewConnector(ConnectorConfig{Addr: addr, ShutdownTimeout: 10 * time.Millisecond}, router, noopLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Connect(ctx)
+	}()
+
+	go func() {
+		for {
+			res, err := http.Post("http://"+addr+"/judge", "application/json", strings.NewReader(`{}`))
+			if err == nil {
+				res.Body.Close()
+				return
+			}
+			select {
+			case <-router.started:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	<-router.started
+
+	cancel()
+	<-done
+	assert.True(t, router.finished.Load(), "requests canceled after the shutdown timeout must be done before Connect returns")
+}
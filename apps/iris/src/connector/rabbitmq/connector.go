@@ -2,17 +2,22 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	instrumentation "github.com/skkuding/codedang/apps/iris/src"
 	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/handler"
 	"github.com/skkuding/codedang/apps/iris/src/router"
 	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/health"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -20,64 +25,203 @@ import (
 const (
 	MessageTimeoutEnv       = "IRIS_MESSAGE_TIMEOUT_MS"
 	DefaultMessageTimeoutMS = 10 * 60 * 1000
+	// ShutdownTimeoutEnv bounds how long messages being handled may take to
+	// finish once iris is asked to stop. Tasks still running afterwards are
+	// canceled.
+	ShutdownTimeoutEnv       = "IRIS_SHUTDOWN_TIMEOUT_MS"
+	DefaultShutdownTimeoutMS = 25 * 1000
 )
 
+var errShuttingDown = errors.New("iris is shutting down")
+
 type connector struct {
 	consumer Consumer
 	producer Producer
 	router   router.Router
+	retry    retryConfig
 	Done     chan error
 	logger   logger.Logger
+	// inFlight counts the messages being handled.
+	inFlight sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 func NewConnector(
 	consumer Consumer,
 	producer Producer,
 	router router.Router,
-	logger logger.Logger,
+	logProvider logger.Logger,
 ) *connector {
-	return &connector{consumer, producer, router, make(chan error), logger}
+	retry, err := retryConfigFromEnv()
+	if err != nil {
+		logProvider.Log(logger.WARN, fmt.Sprintf("invalid retry config: %v; using defaults", err))
+		retry = defaultRetryConfig()
+	}
+	return &connector{
+		consumer: consumer,
+		producer: producer,
+		router:   router,
+		retry:    retry,
+		Done:     make(chan error),
+		logger:   logProvider,
+		stop:     make(chan struct{}),
+	}
 }
 
+// Connect consumes messages until ctx is done or Disconnect is called.
+// Whenever the connection or a channel to the broker is lost, it reports
+// unhealthy and reconnects with exponential backoff. On the way out it stops
+// consuming and drains the messages being handled before closing the
+// connections.
 func (c *connector) Connect(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// task는 ctx가 끝난 뒤에도 drain 동안 계속 실행되어야 함
+	handleCtx, cancelHandles := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelHandles(nil)
+
 	defer func() {
 		c.consumer.CleanUp()
 		c.producer.CleanUp()
 	}()
 
-	err := c.consumer.OpenChannel()
+	backoff, err := reconnectBackoffFromEnv()
 	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to open channel: %s", err))
+		c.logger.Log(logger.WARN, fmt.Sprintf("invalid reconnect backoff: %v; using default", err))
+		backoff = time.Duration(DefaultReconnectBackoffMS) * time.Millisecond
+	}
+
+	health.SetDown(healthComponent, errors.New("not connected yet"))
+	for {
+		err := c.serve(ctx, handleCtx)
+		if ctx.Err() != nil {
+			break
+		}
+		health.SetDown(healthComponent, err)
+		c.logger.Log(logger.ERROR, fmt.Sprintf("lost connection to broker: %s", err))
+
+		if !c.reconnectWithBackoff(ctx, backoff) {
+			break
+		}
+	}
+
+	c.drain(cancelHandles)
+	c.logger.Log(logger.DEBUG, "connector done")
+}
+
+// reconnectWithBackoff retries until it reconnects or ctx is done, and
+// reports which of both happened.
+func (c *connector) reconnectWithBackoff(ctx context.Context, backoff time.Duration) bool {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(reconnectDelay(backoff, attempt)):
+		case <-ctx.Done():
+			return false
+		}
+		if err := c.reconnect(); err != nil {
+			c.logger.Log(logger.WARN, fmt.Sprintf("reconnect attempt %d failed: %s", attempt+1, err))
+			continue
+		}
+		c.logger.Log(logger.INFO, "reconnected to broker")
+		return true
+	}
+}
+
+// drain stops consuming and waits for the messages being handled. Tasks still
+// running after the shutdown timeout are canceled, which reports them as
+// CANCELED once they stopped.
+func (c *connector) drain(cancelHandles context.CancelCauseFunc) {
+	health.SetDown(healthComponent, errShuttingDown)
+	if err := c.consumer.Cancel(); err != nil {
+		c.logger.Log(logger.WARN, fmt.Sprintf("failed to stop consuming: %s", err))
 	}
 
-	err = c.producer.OpenChannel()
+	timeout, err := shutdownTimeoutFromEnv()
 	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to open channel: %s", err))
+		c.logger.Log(logger.WARN, fmt.Sprintf("%v; using default", err))
+		timeout = time.Duration(DefaultShutdownTimeoutMS) * time.Millisecond
 	}
 
-	messageCh, err := c.consumer.Subscribe()
+	drained := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(drained)
+	}()
+
+	c.logger.Log(logger.INFO, fmt.Sprintf("draining messages being handled for up to %s", timeout))
+	select {
+	case <-drained:
+		return
+	case <-time.After(timeout):
+	}
+	c.logger.Log(logger.WARN, "shutdown timeout exceeded; canceling remaining tasks")
+	cancelHandles(errShuttingDown)
+	<-drained
+}
+
+// serve opens the channels and dispatches messages until the connection to
+// the broker is lost or ctx is done. Messages are handled with handleCtx.
+func (c *connector) serve(ctx context.Context, handleCtx context.Context) error {
+	if err := c.consumer.OpenChannel(); err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := c.producer.OpenChannel(); err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	messageCh, err := c.consumer.Subscribe(done)
 	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to subscribe: %s", err))
+		return fmt.Errorf("failed to subscribe: %w", err)
 	}
+	consumerClosed := c.consumer.NotifyClose()
+	producerClosed := c.producer.NotifyClose()
+	health.SetUp(healthComponent)
 
 	// [mq.ingress]     consume -> handle -> 														  produce
 	// [mq.controller]							| controller -> 	controller(result) -> |
 	// [handler]													  | handler -> |
-	// i.consume(messageCh, i.Done)
-	for message := range messageCh {
-		go c.handle(message, ctx)
+	for {
+		select {
+		case message, ok := <-messageCh:
+			if !ok {
+				return errors.New("consumer channel closed")
+			}
+			c.inFlight.Add(1)
+			go func() {
+				defer c.inFlight.Done()
+				c.handle(message, handleCtx)
+			}()
+		case err := <-consumerClosed:
+			return fmt.Errorf("consumer closed: %v", err)
+		case err := <-producerClosed:
+			return fmt.Errorf("producer closed: %v", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+}
 
-	c.logger.Log(logger.DEBUG, "connector done")
-	// running until Consumer is done
-	// <-i.Done
-
-	// if err := i.consumer.CleanUp(); err != nil {
-	// 	i.logger.Error(fmt.Sprintf("failed to clean up the consumer: %s", err))
-	// }
+func (c *connector) reconnect() error {
+	if err := c.consumer.Reconnect(); err != nil {
+		return err
+	}
+	return c.producer.Reconnect()
 }
 
-func (c *connector) Disconnect() {}
+// Disconnect makes Connect stop as if its context was done.
+func (c *connector) Disconnect() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
 
 func (c *connector) handle(message amqp.Delivery, ctx context.Context) {
 	carrier := convertTableToHeaderCarrier(message.Headers)
@@ -99,6 +243,15 @@ func (c *connector) handle(message amqp.Delivery, ctx context.Context) {
 	}
 	spanCtx, cancel := context.WithTimeout(spanCtx, timeout)
 	defer cancel()
+	if lane := LaneOf(message); lane != "" {
+		spanCtx = handler.WithLane(spanCtx, lane)
+	}
+
+	// 반복해서 재전달되는 메시지는 iris를 죽이는 poison message로 보고 격리
+	if count := deliveryCount(message); count > c.retry.maxRedeliveries {
+		c.quarantine(message, count, spanCtx)
+		return
+	}
 
 	resultChan := make(chan response.Response, 1)
 	if message.Type == "" {
@@ -114,6 +267,12 @@ func (c *connector) handle(message amqp.Delivery, ctx context.Context) {
 		}()
 	}
 
+	// failure is set if any result could not be delivered
+	var failure string
+	// canceled is set once the task is canceled by shutdown
+	canceled := false
+	done := spanCtx.Done()
+	publishCtx := spanCtx
 drain:
 	for {
 		var result response.Response
@@ -123,27 +282,127 @@ drain:
 			if !open {
 				break drain
 			}
-		case <-spanCtx.Done():
+		case <-done:
+			if errors.Is(context.Cause(spanCtx), errShuttingDown) {
+				// 종료로 취소된 task는 sandbox 실행이 끝날 때까지 기다린 뒤 CANCELED로 마무리
+				c.logger.LogWithContext(logger.WARN, fmt.Sprintf("message %s canceled by shutdown; waiting for the task to stop", message.MessageId), spanCtx)
+				canceled = true
+				done = nil
+				publishCtx = context.WithoutCancel(spanCtx)
+				continue
+			}
 			c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("message handling timed out after %s", timeout), spanCtx)
+			failure = fmt.Sprintf("message handling timed out after %s", timeout)
+			c.publishError(message, fmt.Errorf("%s", failure), spanCtx)
 			break drain
 		}
 
-		if err := c.producer.Publish(result.Message, spanCtx, string(result.Type)); err != nil {
+		if err := c.publish(result, publishCtx); err != nil {
 			c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to publish result: %s: %s", string(result.Message), err), spanCtx)
-			// nack
+			failure = fmt.Sprintf("failed to publish result: %s", err)
 		} else {
 			c.logger.LogWithContext(logger.DEBUG, fmt.Sprintf("result published: %s", string(result.Message)), spanCtx)
 		}
 	}
 
+	if canceled {
+		c.publishCanceled(message, spanCtx)
+	}
+
+	if failure != "" {
+		c.deadLetter(message, failure, spanCtx)
+		return
+	}
+
 	if err := message.Ack(false); err != nil {
 		c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to ack message: %s: %s", string(message.Body), err), spanCtx)
-		// retry
 	} else {
 		c.logger.LogWithContext(logger.DEBUG, "message ack", spanCtx)
 	}
 }
 
+// publish retries a failed publish with backoff.
+func (c *connector) publish(result response.Response, ctx context.Context) error {
+	return c.retry.withRetry(ctx, func() error {
+		return c.producer.Publish(result.Message, ctx, string(result.Type))
+	})
+}
+
+// publishError reports err as the result of message, so that the submission
+// does not stay in judging. It is best effort as the message is dead-lettered
+// anyway.
+func (c *connector) publishError(message amqp.Delivery, err error, ctx context.Context) {
+	result := response.Response{
+		Message: response.NewJudgeResponse(message.MessageId, nil, err).Marshal(),
+		Type:    constants.MessageType(message.Type),
+	}
+	if result.Type == "" {
+		result.Type = constants.Default
+	}
+	if err := c.publish(result, context.WithoutCancel(ctx)); err != nil {
+		c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to publish error result: %s", err), ctx)
+	}
+}
+
+// publishCanceled reports message as canceled by shutdown. The router drops
+// the results of a canceled task, so a finished submission response follows
+// for submission tasks.
+func (c *connector) publishCanceled(message amqp.Delivery, ctx context.Context) {
+	taskErr := handler.NewTaskError("connector", handler.CANCELED, logger.INFO, errShuttingDown)
+	c.publishError(message, taskErr, ctx)
+
+	messageType := constants.MessageType(message.Type)
+	if !router.IsSubmissionTask(messageType) {
+		return
+	}
+	judgeResponse := response.NewJudgeResponse(message.MessageId, nil, taskErr)
+	result := response.Response{
+		Message: response.NewSubmissionResponse(message.MessageId, []*response.JudgeResponse{judgeResponse}).Marshal(),
+		Type:    constants.Submission,
+	}
+	if err := c.publish(result, context.WithoutCancel(ctx)); err != nil {
+		c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to publish canceled submission: %s", err), ctx)
+	}
+}
+
+func (c *connector) quarantine(message amqp.Delivery, count int, ctx context.Context) {
+	reason := fmt.Sprintf("poison message: delivered %d times", count+1)
+	c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("quarantining message %s: %s", message.MessageId, reason), ctx)
+	c.publishError(message, fmt.Errorf("%s", reason), ctx)
+	c.deadLetter(message, reason, ctx)
+}
+
+// deadLetter moves message to the dead-letter exchange. Without one, or if
+// that fails, the message is rejected without requeue so that the queue's
+// dead-letter policy, if any, applies.
+func (c *connector) deadLetter(message amqp.Delivery, reason string, ctx context.Context) {
+	// 처리 시간 초과 후에도 dead-letter는 보내야 하므로 취소를 끊음
+	ctx = context.WithoutCancel(ctx)
+	err := c.retry.withRetry(ctx, func() error {
+		return c.producer.DeadLetter(message, reason, ctx)
+	})
+	if err == nil {
+		if err := message.Ack(false); err != nil {
+			c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to ack dead-lettered message: %s", err), ctx)
+		}
+		return
+	}
+
+	if !errors.Is(err, errNoDeadLetterExchange) {
+		c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to dead-letter message %s: %s", message.MessageId, err), ctx)
+	}
+	if err := message.Nack(false, false); err != nil {
+		c.logger.LogWithContext(logger.ERROR, fmt.Sprintf("failed to nack message: %s: %s", string(message.Body), err), ctx)
+	} else {
+		c.logger.LogWithContext(logger.WARN, fmt.Sprintf("message %s rejected: %s", message.MessageId, reason), ctx)
+	}
+}
+
+func shutdownTimeoutFromEnv() (time.Duration, error) {
+	milliseconds, err := utils.GetenvNonNegativeInt(ShutdownTimeoutEnv, DefaultShutdownTimeoutMS)
+	return time.Duration(milliseconds) * time.Millisecond, err
+}
+
 func messageTimeoutFromEnv() (time.Duration, error) {
 	raw := os.Getenv(MessageTimeoutEnv)
 	if raw == "" {
@@ -1,9 +1,19 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/health"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -29,3 +39,361 @@ func TestMessageTimeoutFromEnv(t *testing.T) {
 		assert.EqualError(t, err, "must be a positive integer")
 	})
 }
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+type fakeAcknowledger struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack(_ uint64, _ bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.nacked, a.requeue = true, requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(_ uint64, requeue bool) error {
+	return a.Nack(0, false, requeue)
+}
+
+// fakeProducer fails the first failures publishes.
+type fakeProducer struct {
+	failures    int
+	published   []string
+	messages    []string
+	deadLetters []string
+	noDLX       bool
+}
+
+func (p *fakeProducer) OpenChannel() error              { return nil }
+func (p *fakeProducer) CleanUp() error                  { return nil }
+func (p *fakeProducer) Reconnect() error                { return nil }
+func (p *fakeProducer) NotifyClose() <-chan *amqp.Error { return nil }
+
+func (p *fakeProducer) Publish(result []byte, _ context.Context, messageType string) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("channel closed")
+	}
+	p.published = append(p.published, messageType)
+	p.messages = append(p.messages, string(result))
+	return nil
+}
+
+func (p *fakeProducer) DeadLetter(_ amqp.Delivery, reason string, _ context.Context) error {
+	if p.noDLX {
+		return errNoDeadLetterExchange
+	}
+	p.deadLetters = append(p.deadLetters, reason)
+	return nil
+}
+
+type fakeRouter struct {
+	routed bool
+}
+
+func (r *fakeRouter) Route(path constants.MessageType, _ string, _ []byte, out chan<- response.Response, _ context.Context) {
+	r.routed = true
+	out <- response.Response{Message: []byte(`{}`), Type: path}
+}
+
+func newTestConnector(producer *fakeProducer, router *fakeRouter) *connector {
+	return &connector{
+		producer: producer,
+		router:   router,
+		retry:    retryConfig{publishRetries: 2, publishBackoff: time.Millisecond, maxRedeliveries: 3},
+		logger:   noopLogger{},
+	}
+}
+
+func newTestDelivery(headers amqp.Table) (amqp.Delivery, *fakeAcknowledger) {
+	ack := &fakeAcknowledger{}
+	return amqp.Delivery{
+		Acknowledger: ack,
+		Headers:      headers,
+		MessageId:    "1",
+		Type:         string(constants.Judge),
+		Body:         []byte(`{}`),
+	}, ack
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("retries failed publishes", func(t *testing.T) {
+		producer := &fakeProducer{failures: 2}
+		message, ack := newTestDelivery(nil)
+
+		newTestConnector(producer, &fakeRouter{}).handle(message, context.Background())
+
+		assert.Equal(t, []string{"judge"}, producer.published)
+		assert.Empty(t, producer.deadLetters)
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("dead-letters when publishing keeps failing", func(t *testing.T) {
+		producer := &fakeProducer{failures: 3}
+		message, ack := newTestDelivery(nil)
+
+		newTestConnector(producer, &fakeRouter{}).handle(message, context.Background())
+
+		require.Len(t, producer.deadLetters, 1)
+		assert.Contains(t, producer.deadLetters[0], "failed to publish result")
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("rejects without a dead-letter exchange", func(t *testing.T) {
+		producer := &fakeProducer{failures: 3, noDLX: true}
+		message, ack := newTestDelivery(nil)
+
+		newTestConnector(producer, &fakeRouter{}).handle(message, context.Background())
+
+		assert.False(t, ack.acked)
+		assert.True(t, ack.nacked)
+		assert.False(t, ack.requeue)
+	})
+
+	t.Run("quarantines poison messages", func(t *testing.T) {
+		producer := &fakeProducer{}
+		router := &fakeRouter{}
+		message, ack := newTestDelivery(amqp.Table{DeliveryCountHeader: int64(4)})
+
+		newTestConnector(producer, router).handle(message, context.Background())
+
+		assert.False(t, router.routed)
+		assert.Equal(t, []string{"judge"}, producer.published)
+		assert.Equal(t, []string{"poison message: delivered 5 times"}, producer.deadLetters)
+		assert.True(t, ack.acked)
+	})
+}
+
+func TestRetryConfig(t *testing.T) {
+	t.Run("backs off exponentially up to a cap", func(t *testing.T) {
+		retry := retryConfig{publishBackoff: 100 * time.Millisecond}
+		assert.Equal(t, 100*time.Millisecond, retry.backoff(0))
+		assert.Equal(t, 400*time.Millisecond, retry.backoff(2))
+		assert.Equal(t, maxPublishBackoff, retry.backoff(20))
+	})
+
+	t.Run("reads the environment", func(t *testing.T) {
+		t.Setenv(PublishRetryCountEnv, "5")
+		t.Setenv(PublishRetryBackoffEnv, "")
+		t.Setenv(MaxRedeliveriesEnv, "0")
+		retry, err := retryConfigFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, 5, retry.publishRetries)
+		assert.Equal(t, time.Duration(DefaultPublishBackoffMS)*time.Millisecond, retry.publishBackoff)
+		assert.Equal(t, 0, retry.maxRedeliveries)
+	})
+
+	t.Run("rejects negative values", func(t *testing.T) {
+		t.Setenv(PublishRetryCountEnv, "-1")
+		_, err := retryConfigFromEnv()
+		assert.EqualError(t, err, "RABBITMQ_PUBLISH_RETRY_COUNT must be a non-negative integer")
+	})
+}
+
+func TestDeliveryCount(t *testing.T) {
+	assert.Equal(t, 0, deliveryCount(amqp.Delivery{}))
+	assert.Equal(t, 1, deliveryCount(amqp.Delivery{Redelivered: true}))
+	assert.Equal(t, 7, deliveryCount(amqp.Delivery{Headers: amqp.Table{DeliveryCountHeader: int32(7)}}))
+}
+
+func TestLaneOf(t *testing.T) {
+	assert.Equal(t, "", LaneOf(amqp.Delivery{ConsumerTag: "consumer-tag"}))
+	assert.Equal(t, "contest", LaneOf(amqp.Delivery{ConsumerTag: "consumer-tag:contest"}))
+	assert.Equal(t, "contest", LaneOf(amqp.Delivery{
+		ConsumerTag: "consumer-tag:practice",
+		Headers:     amqp.Table{LaneHeader: "contest"},
+	}))
+}
+
+// fakeConsumer hands out one delivery channel per connection. Closing it
+// simulates a lost connection.
+type fakeConsumer struct {
+	mu          sync.Mutex
+	deliveries  []chan amqp.Delivery
+	subscribed  chan int
+	reconnected int
+	canceled    bool
+}
+
+func (c *fakeConsumer) OpenChannel() error              { return nil }
+func (c *fakeConsumer) CleanUp() error                  { return nil }
+func (c *fakeConsumer) NotifyClose() <-chan *amqp.Error { return nil }
+
+func (c *fakeConsumer) Subscribe(<-chan struct{}) (<-chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	deliveries := make(chan amqp.Delivery)
+	c.deliveries = append(c.deliveries, deliveries)
+	c.subscribed <- len(c.deliveries)
+	return deliveries, nil
+}
+
+func (c *fakeConsumer) Cancel() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.canceled = true
+	return nil
+}
+
+func (c *fakeConsumer) Reconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnected++
+	return nil
+}
+
+func TestConnect(t *testing.T) {
+	t.Setenv(ReconnectBackoffEnv, "1")
+	consumer := &fakeConsumer{subscribed: make(chan int, 2)}
+	c := NewConnector(consumer, &fakeProducer{}, &fakeRouter{}, noopLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Connect(ctx)
+	}()
+
+	healthy := func() bool { return health.Check() == nil }
+
+	assert.Equal(t, 1, <-consumer.subscribed)
+	assert.Eventually(t, healthy, time.Second, time.Millisecond)
+
+	consumer.mu.Lock()
+	close(consumer.deliveries[0])
+	consumer.mu.Unlock()
+
+	assert.Equal(t, 2, <-consumer.subscribed)
+	consumer.mu.Lock()
+	assert.Equal(t, 1, consumer.reconnected)
+	consumer.mu.Unlock()
+	assert.Eventually(t, healthy, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestReconnectDelay(t *testing.T) {
+	assert.Equal(t, time.Second, reconnectDelay(time.Second, 0))
+	assert.Equal(t, 4*time.Second, reconnectDelay(time.Second, 2))
+	assert.Equal(t, maxReconnectBackoff, reconnectDelay(time.Second, 10))
+}
+
+// blockingRouter answers once release is closed, and gives up without an
+// answer when ctx is done like the real router does. If stopped is set, it
+// returns only once stopped is closed as a task waiting for its sandbox does.
+type blockingRouter struct {
+	started chan struct{}
+	release chan struct{}
+	stopped chan struct{}
+}
+
+func (r *blockingRouter) Route(path constants.MessageType, _ string, _ []byte, out chan<- response.Response, ctx context.Context) {
+	close(r.started)
+	select {
+	case <-r.release:
+		out <- response.Response{Message: []byte(`{}`), Type: path}
+	case <-ctx.Done():
+		if r.stopped != nil {
+			<-r.stopped
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	start := func(router *blockingRouter, producer *fakeProducer) (*fakeConsumer, *fakeAcknowledger, context.CancelFunc, chan struct{}) {
+		consumer := &fakeConsumer{subscribed: make(chan int, 1)}
+		c := NewConnector(consumer, producer, router, noopLogger{})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.Connect(ctx)
+		}()
+
+		<-consumer.subscribed
+		message, ack := newTestDelivery(nil)
+		consumer.mu.Lock()
+		deliveries := consumer.deliveries[0]
+		consumer.mu.Unlock()
+		deliveries <- message
+		<-router.started
+		return consumer, ack, cancel, done
+	}
+
+	t.Run("drains messages being handled", func(t *testing.T) {
+		t.Setenv(ShutdownTimeoutEnv, "")
+		router := &blockingRouter{started: make(chan struct{}), release: make(chan struct{})}
+		producer := &fakeProducer{}
+		consumer, ack, cancel, done := start(router, producer)
+
+		cancel()
+		select {
+		case <-done:
+			t.Fatal("connector stopped before the message was handled")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(router.release)
+		<-done
+
+		assert.True(t, consumer.canceled)
+		assert.Equal(t, []string{`{}`}, producer.messages)
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("cancels tasks after the shutdown timeout", func(t *testing.T) {
+		t.Setenv(ShutdownTimeoutEnv, "10")
+		router := &blockingRouter{started: make(chan struct{}), release: make(chan struct{})}
+		producer := &fakeProducer{}
+		_, ack, cancel, done := start(router, producer)
+
+		cancel()
+		<-done
+
+		require.Len(t, producer.messages, 2)
+		assert.Contains(t, producer.messages[0], fmt.Sprintf(`"resultCode":%d`, handler.CANCELED))
+		assert.Equal(t, []string{string(constants.Judge), string(constants.Submission)}, producer.published)
+		assert.Contains(t, producer.messages[1], `"finished":true`)
+		assert.Empty(t, producer.deadLetters)
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("waits for canceled tasks to stop", func(t *testing.T) {
+		t.Setenv(ShutdownTimeoutEnv, "10")
+		router := &blockingRouter{started: make(chan struct{}), release: make(chan struct{}), stopped: make(chan struct{})}
+		producer := &fakeProducer{}
+		_, ack, cancel, done := start(router, producer)
+
+		cancel()
+		select {
+		case <-done:
+			t.Fatal("connector stopped before the canceled task stopped")
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.False(t, ack.acked)
+		close(router.stopped)
+		<-done
+
+		require.Len(t, producer.messages, 2)
+		assert.True(t, ack.acked)
+	})
+}
+
+func TestShutdownTimeoutFromEnv(t *testing.T) {
+	t.Setenv(ShutdownTimeoutEnv, "-1")
+	_, err := shutdownTimeoutFromEnv()
+	assert.EqualError(t, err, "IRIS_SHUTDOWN_TIMEOUT_MS must be a non-negative integer")
+}
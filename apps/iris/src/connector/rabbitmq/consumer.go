@@ -1,59 +1,95 @@
 package rabbitmq
 
 import (
-	"crypto/tls"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	PrefetchCountEnv     = "RABBITMQ_PREFETCH_COUNT"
+	DefaultPrefetchCount = 1
+	// LaneHeader lets the publisher put a message in a lane other than the
+	// one of the queue it was published to.
+	LaneHeader = "x-iris-lane"
 )
 
 type Consumer interface {
 	OpenChannel() error
-	Subscribe() (<-chan amqp.Delivery, error)
+	// Subscribe stops forwarding messages once done is closed.
+	Subscribe(done <-chan struct{}) (<-chan amqp.Delivery, error)
+	// NotifyClose must be called after OpenChannel.
+	NotifyClose() <-chan *amqp.Error
+	Reconnect() error
+	// Cancel stops consuming while the channel stays open, so that messages
+	// already delivered can still be acknowledged.
+	Cancel() error
 	CleanUp() error
 	// Ack(channelName string, tag uint64) error
 }
 
 type consumer struct {
-	connection *amqp.Connection
-	channel    *amqp.Channel
-	queueName  string
-	tag        string
-	Done       chan error
-	logger     logger.Logger
+	connection     *amqp.Connection
+	channel        *amqp.Channel
+	uri            string
+	connectionName string
+	lanes          []Lane
+	tag            string
+	prefetchCount  int
+	logger         logger.Logger
 }
 
 type ConsumerConfig struct {
 	AmqpURI        string
 	ConnectionName string
-	QueueName      string
-	Ctag           string
+	// QueueName is consumed as the only lane if Lanes is empty.
+	QueueName string
+	Lanes     []Lane
+	Ctag      string
+	// PrefetchCount is the number of unacknowledged messages per lane unless
+	// RABBITMQ_PREFETCH_COUNT is set. Zero means DefaultPrefetchCount.
+	PrefetchCount int
 }
 
-func NewConsumer(config ConsumerConfig, logger logger.Logger) (*consumer, error) {
+// Lane is a queue consumed on its own. Deliveries of a lane carry a consumer
+// tag of "<Ctag>:<Name>", or just Ctag if Name is empty, see LaneOf.
+type Lane struct {
+	Name      string
+	QueueName string
+	// Exchange, if set, is a fanout exchange consumed through a queue of
+	// this connection alone instead of QueueName, so that every instance
+	// receives all of its messages.
+	Exchange string
+}
 
-	// Create New RabbitMQ Connection (go <-> RabbitMQ)
-	amqpConfig := amqp.Config{
-		Properties: amqp.NewConnectionProperties(),
-	}
-	if os.Getenv("RABBITMQ_SSL") == "true" {
-		amqpConfig.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-	amqpConfig.Properties.SetClientConnectionName(config.ConnectionName)
-	connection, err := amqp.DialConfig(config.AmqpURI, amqpConfig)
+func NewConsumer(config ConsumerConfig, logger logger.Logger) (*consumer, error) {
+	connection, err := dial(config.AmqpURI, config.ConnectionName)
 	if err != nil {
 		return nil, fmt.Errorf("consumer: dial failed: %w", err)
 	}
 
+	lanes := config.Lanes
+	if len(lanes) == 0 {
+		lanes = []Lane{{Name: "", QueueName: config.QueueName}}
+	}
+	prefetchCount := config.PrefetchCount
+	if prefetchCount <= 0 {
+		prefetchCount = DefaultPrefetchCount
+	}
+
 	return &consumer{
-		connection: connection,
-		channel:    nil,
-		queueName:  config.QueueName,
-		tag:        config.Ctag,
-		Done:       make(chan error),
-		logger:     logger,
+		connection:     connection,
+		channel:        nil,
+		uri:            config.AmqpURI,
+		connectionName: config.ConnectionName,
+		lanes:          lanes,
+		tag:            config.Ctag,
+		prefetchCount:  prefetchCount,
+		logger:         logger,
 	}, nil
 }
 
@@ -64,51 +100,162 @@ func (c *consumer) OpenChannel() error {
 		return fmt.Errorf("channel: %s", err)
 	}
 	// Set prefetchCount for consume channel
+	// 동시에 처리할 메시지 수는 router의 admission이 task 종류별로 제한
+	prefetchCount, err := prefetchCountFromEnv(c.prefetchCount)
+	if err != nil {
+		return err
+	}
 	if err = c.channel.Qos(
-		1,     // prefetchCount
-		0,     // prefetchSize
-		false, // global
+		prefetchCount, // prefetchCount
+		0,             // prefetchSize
+		false,         // global
 	); err != nil {
 		return fmt.Errorf("qos set: %s", err)
 	}
 	return nil
 }
 
-func (c *consumer) Subscribe() (<-chan amqp.Delivery, error) {
-
-	// Subscribe queue for consume messages
-	// Return `<- chan Delivery`
-	messages, err := c.channel.Consume(
-		c.queueName, // queue name
-		c.tag,       // consumer
-		false,       // autoAck
-		false,       // exclusive
-		false,       // noLocal
-		false,       // noWait
-		nil,         // arguments
+func prefetchCountFromEnv(fallback int) (int, error) {
+	return utils.GetenvPositiveInt(PrefetchCountEnv, fallback)
+}
+
+// Subscribe consumes the queue of every lane. The returned channel is closed
+// once all of them are. Closing done releases the goroutines merging them
+// when nobody reads the returned channel anymore, e.g. after a reconnect;
+// messages not forwarded by then are redelivered by the broker.
+func (c *consumer) Subscribe(done <-chan struct{}) (<-chan amqp.Delivery, error) {
+	var wg sync.WaitGroup
+	merged := make(chan amqp.Delivery)
+
+	for _, lane := range c.lanes {
+		queueName := lane.QueueName
+		if lane.Exchange != "" {
+			var err error
+			if queueName, err = c.declareFanoutQueue(lane.Exchange); err != nil {
+				return nil, err
+			}
+		}
+
+		// Subscribe queue for consume messages
+		// Return `<- chan Delivery`
+		messages, err := c.channel.Consume(
+			queueName,            // queue name
+			c.laneTag(lane.Name), // consumer
+			false,                // autoAck
+			false,                // exclusive
+			false,                // noLocal
+			false,                // noWait
+			nil,                  // arguments
+		)
+		if err != nil {
+			return nil, fmt.Errorf("queue consume %s: %s", queueName, err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range messages {
+				select {
+				case merged <- message:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged, nil
+}
+
+// declareFanoutQueue binds a server-named queue to the fanout exchange. The
+// queue is exclusive to the connection and deleted with it, so it has to be
+// declared again after a reconnect.
+func (c *consumer) declareFanoutQueue(exchange string) (string, error) {
+	if err := c.channel.ExchangeDeclare(
+		exchange,            // name
+		amqp.ExchangeFanout, // type
+		true,                // durable
+		false,               // autoDelete
+		false,               // internal
+		false,               // noWait
+		nil,                 // arguments
+	); err != nil {
+		return "", fmt.Errorf("exchange declare %s: %s", exchange, err)
+	}
+	queue, err := c.channel.QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // autoDelete
+		true,  // exclusive
+		false, // noWait
+		nil,   // arguments
 	)
 	if err != nil {
-		return nil, fmt.Errorf("queue consume: %s", err)
+		return "", fmt.Errorf("queue declare for %s: %s", exchange, err)
 	}
-	return messages, nil
+	if err := c.channel.QueueBind(queue.Name, "", exchange, false, nil); err != nil {
+		return "", fmt.Errorf("queue bind %s to %s: %s", queue.Name, exchange, err)
+	}
+	return queue.Name, nil
 }
 
-func (c *consumer) CleanUp() error {
+func (c *consumer) laneTag(lane string) string {
+	if lane == "" {
+		return c.tag
+	}
+	return c.tag + ":" + lane
+}
 
-	c.logger.Log(logger.DEBUG, "consumer clean up")
-	// Close channel
-	if err := c.channel.Cancel(c.tag, true); err != nil {
-		return fmt.Errorf("Consumer cancel failed: %w", err)
+// LaneOf returns the lane of message as set by LaneHeader, or else the name
+// of the lane it was consumed from. It is "" if the consumer has no lanes.
+func LaneOf(message amqp.Delivery) string {
+	if lane, ok := message.Headers[LaneHeader].(string); ok && lane != "" {
+		return lane
+	}
+	if i := strings.LastIndex(message.ConsumerTag, ":"); i >= 0 {
+		return message.ConsumerTag[i+1:]
 	}
+	return ""
+}
+
+func (c *consumer) NotifyClose() <-chan *amqp.Error {
+	return notifyClose(c.connection, c.channel)
+}
 
+// Reconnect replaces the connection. The channel has to be opened and
+// subscribed again afterwards.
+func (c *consumer) Reconnect() error {
+	connection, err := redial(c.connection, c.uri, c.connectionName)
+	if err != nil {
+		return fmt.Errorf("consumer: dial failed: %w", err)
+	}
+	c.connection = connection
+	return nil
+}
+
+func (c *consumer) Cancel() error {
+	for _, lane := range c.lanes {
+		if err := c.channel.Cancel(c.laneTag(lane.Name), false); err != nil {
+			return fmt.Errorf("Consumer cancel failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *consumer) CleanUp() error {
+
+	c.logger.Log(logger.DEBUG, "consumer clean up")
 	// Close Connection
+	// 채널도 함께 닫히며, ack되지 않은 메시지는 broker가 다시 큐에 넣음
 	if err := c.connection.Close(); err != nil {
 		return fmt.Errorf("AMQP connection close error: %s", err)
 	}
-	defer c.logger.Log(logger.INFO, "RabbitMQ connection clear done")
-
-	// wait for handle() to exit
-	return <-c.Done
+	c.logger.Log(logger.INFO, "RabbitMQ connection clear done")
+	return nil
 }
 
 // func (c *consumer) Ack(channelName string, tag uint64) error {
@@ -2,10 +2,11 @@ package rabbitmq
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	instrumentation "github.com/skkuding/codedang/apps/iris/src"
@@ -15,20 +16,33 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+var errNoDeadLetterExchange = errors.New("no dead-letter exchange configured")
+
+// confirmTimeout bounds how long a publish waits for the broker to confirm it.
+const confirmTimeout = 30 * time.Second
+
 type Producer interface {
 	OpenChannel() error
 	Publish([]byte, context.Context, string) error
+	DeadLetter(amqp.Delivery, string, context.Context) error
+	// NotifyClose must be called after OpenChannel.
+	NotifyClose() <-chan *amqp.Error
+	Reconnect() error
 	CleanUp() error
 }
 
 type producer struct {
-	connection   *amqp.Connection
-	channel      *amqp.Channel
-	exchangeName string
-	routingKey   string
-	Done         chan error
-	publishes    chan uint64
-	logger       logger.Logger
+	// mu guards connection and channel, which are replaced on reconnect while
+	// results are being published.
+	mu                 sync.RWMutex
+	connection         *amqp.Connection
+	channel            *amqp.Channel
+	uri                string
+	connectionName     string
+	exchangeName       string
+	routingKey         string
+	deadLetterExchange string
+	logger             logger.Logger
 }
 
 type ProducerConfig struct {
@@ -36,37 +50,35 @@ type ProducerConfig struct {
 	ConnectionName string
 	ExchangeName   string
 	RoutingKey     string
+	// DeadLetterExchangeName receives messages that could not be processed.
+	// If empty, they are rejected and left to the queue's own dead-letter
+	// policy.
+	DeadLetterExchangeName string
 }
 
 func NewProducer(config ProducerConfig, logger logger.Logger) (*producer, error) {
-
-	// Create New RabbitMQ Connection (go <-> RabbitMQ)
-	amqpConfig := amqp.Config{
-		Properties: amqp.NewConnectionProperties(),
-	}
-	if os.Getenv("RABBITMQ_SSL") == "true" {
-		amqpConfig.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-	amqpConfig.Properties.SetClientConnectionName(config.ConnectionName)
-	connection, err := amqp.DialConfig(config.AmqpURI, amqpConfig)
+	connection, err := dial(config.AmqpURI, config.ConnectionName)
 	if err != nil {
 		return nil, fmt.Errorf("consumer: dial failed: %w", err)
 	}
 
 	return &producer{
-		connection:   connection,
-		channel:      nil,
-		exchangeName: config.ExchangeName,
-		routingKey:   config.RoutingKey,
-		Done:         make(chan error),
-		publishes:    make(chan uint64, 8),
-		logger:       logger,
+		connection:         connection,
+		channel:            nil,
+		uri:                config.AmqpURI,
+		connectionName:     config.ConnectionName,
+		exchangeName:       config.ExchangeName,
+		routingKey:         config.RoutingKey,
+		deadLetterExchange: config.DeadLetterExchangeName,
+		logger:             logger,
 	}, nil
 }
 
 func (p *producer) OpenChannel() error {
-	var err error
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
+	var err error
 	if p.channel, err = p.connection.Channel(); err != nil {
 		return fmt.Errorf("channel: %s", err)
 	}
@@ -76,38 +88,36 @@ func (p *producer) OpenChannel() error {
 	if err := p.channel.Confirm(false); err != nil {
 		return fmt.Errorf("channel could not be put into confirm mode: %s", err)
 	}
-	// add listner for confirmation
-	confirms := p.channel.NotifyPublish(make(chan amqp.Confirmation, 10))
-
-	go p.confirmHandler(confirms)
-
 	return nil
 }
 
-func (p *producer) confirmHandler(confirms chan amqp.Confirmation) {
-	m := make(map[uint64]bool)
-	for {
-		select {
-		case <-p.Done:
-			p.logger.Log(logger.INFO, "confirmHandler is stopping")
-			return
-		case publishSeqNo := <-p.publishes:
-			// log.Printf("waiting for confirmation of %d", publishSeqNo)
-			m[publishSeqNo] = false
-		case confirmed := <-confirms:
-			p.logger.Log(logger.DEBUG, fmt.Sprintf("tag : %d", confirmed.DeliveryTag))
-			if confirmed.DeliveryTag > 0 {
-				if confirmed.Ack {
-					p.logger.Log(logger.DEBUG, fmt.Sprintf("confirmed delivery with delivery tag: %d", confirmed.DeliveryTag))
-				} else {
-					p.logger.Log(logger.ERROR, fmt.Sprintf("failed delivery of delivery tag: %d", confirmed.DeliveryTag))
-				}
-				delete(m, confirmed.DeliveryTag)
-			} else {
-				p.logger.Panic(fmt.Sprintf("delivery tag must be a positive integer value: received: %d", confirmed.DeliveryTag))
-			}
-		}
+// publish waits for the broker to confirm publishing, so that a message is
+// acked only once its result is stored. A channel closed in the meantime
+// nacks it.
+func (p *producer) publish(ctx context.Context, exchange string, key string, publishing amqp.Publishing) error {
+	p.mu.RLock()
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx,
+		exchange,
+		key,
+		false, // mandatory
+		false, // immediate
+		publishing,
+	)
+	p.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for confirm: %w", err)
+	}
+	if !acked {
+		return errors.New("nacked by broker")
 	}
+	return nil
 }
 
 func (p *producer) Publish(result []byte, ctx context.Context, messageType string) error {
@@ -121,7 +131,6 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 	)
 	defer childSpan.End()
 
-	seqNo := p.channel.GetNextPublishSeqNo()
 	p.logger.Log(logger.INFO, fmt.Sprintf("publishing %dB body", len(result)))
 	p.logger.Log(logger.INFO, string(result))
 
@@ -131,11 +140,9 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 	headers := convertHeaderCarrierToTable(carrier)
 
 	// https://www.rabbitmq.com/publishers.html
-	if err := p.channel.PublishWithContext(spanCtx,
+	if err := p.publish(spanCtx,
 		p.exchangeName, // publish to an exchange
 		p.routingKey,   // routing to 0 or more queues
-		false,          // mandatory
-		false,          // immediate
 		amqp.Publishing{
 			Headers:         headers,
 			ContentType:     "application/json",
@@ -150,11 +157,67 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 	}
 
 	p.logger.Log(logger.DEBUG, fmt.Sprintf("published %dB OK", len(result)))
-	p.publishes <- seqNo
+	return nil
+}
+
+// DeadLetter republishes message to the dead-letter exchange with its routing
+// key, headers and properties, adding why and when it failed.
+func (p *producer) DeadLetter(message amqp.Delivery, reason string, ctx context.Context) error {
+	if p.deadLetterExchange == "" {
+		return errNoDeadLetterExchange
+	}
+
+	headers := amqp.Table{}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	headers[FailureReasonHeader] = reason
+	headers[FailedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+
+	if err := p.publish(ctx,
+		p.deadLetterExchange,
+		message.RoutingKey,
+		amqp.Publishing{
+			Headers:         headers,
+			ContentType:     message.ContentType,
+			ContentEncoding: message.ContentEncoding,
+			Body:            message.Body,
+			DeliveryMode:    amqp.Persistent,
+			MessageId:       message.MessageId,
+			Type:            message.Type,
+		},
+	); err != nil {
+		return fmt.Errorf("dead-letter publish: %s", err)
+	}
+
+	p.logger.Log(logger.WARN, fmt.Sprintf("message %s dead-lettered: %s", message.MessageId, reason))
+	return nil
+}
+
+func (p *producer) NotifyClose() <-chan *amqp.Error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return notifyClose(p.connection, p.channel)
+}
+
+// Reconnect replaces the connection. The channel has to be opened again
+// afterwards.
+func (p *producer) Reconnect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	connection, err := redial(p.connection, p.uri, p.connectionName)
+	if err != nil {
+		return fmt.Errorf("producer: dial failed: %w", err)
+	}
+	p.connection = connection
 	return nil
 }
 
 func (p *producer) CleanUp() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if err := p.channel.Close(); err != nil {
 		return fmt.Errorf("channel close failed: %s", err)
 	}
@@ -163,5 +226,5 @@ func (p *producer) CleanUp() error {
 		return fmt.Errorf("connection close error: %s", err)
 	}
 
-	return <-p.Done
+	return nil
 }
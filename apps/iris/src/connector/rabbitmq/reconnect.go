@@ -0,0 +1,75 @@
+package rabbitmq
+
+import (
+	"crypto/tls"
+	"os"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	ReconnectBackoffEnv       = "RABBITMQ_RECONNECT_BACKOFF_MS"
+	DefaultReconnectBackoffMS = 1000
+
+	maxReconnectBackoff = 30 * time.Second
+	// healthComponent is reported down to the health check while the broker
+	// connection is lost.
+	healthComponent = "rabbitmq"
+)
+
+func dial(uri string, connectionName string) (*amqp.Connection, error) {
+	// Create New RabbitMQ Connection (go <-> RabbitMQ)
+	amqpConfig := amqp.Config{
+		Properties: amqp.NewConnectionProperties(),
+	}
+	if os.Getenv("RABBITMQ_SSL") == "true" {
+		amqpConfig.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	amqpConfig.Properties.SetClientConnectionName(connectionName)
+	return amqp.DialConfig(uri, amqpConfig)
+}
+
+// redial closes connection if it is still open and dials a new one.
+func redial(connection *amqp.Connection, uri string, connectionName string) (*amqp.Connection, error) {
+	if connection != nil && !connection.IsClosed() {
+		connection.Close()
+	}
+	return dial(uri, connectionName)
+}
+
+// notifyClose returns a channel that receives once the connection or the
+// channel is closed. The error is nil on a graceful close.
+func notifyClose(connection *amqp.Connection, channel *amqp.Channel) <-chan *amqp.Error {
+	closed := make(chan *amqp.Error, 1)
+	connectionClosed := connection.NotifyClose(make(chan *amqp.Error, 1))
+	channelClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		select {
+		case err := <-connectionClosed:
+			closed <- err
+		case err := <-channelClosed:
+			closed <- err
+		}
+	}()
+	return closed
+}
+
+func reconnectBackoffFromEnv() (time.Duration, error) {
+	milliseconds, err := utils.GetenvPositiveInt(ReconnectBackoffEnv, DefaultReconnectBackoffMS)
+	return time.Duration(milliseconds) * time.Millisecond, err
+}
+
+// reconnectDelay doubles base on every failed attempt, up to
+// maxReconnectBackoff.
+func reconnectDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for range attempt {
+		delay *= 2
+		if delay >= maxReconnectBackoff {
+			return maxReconnectBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,116 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	PublishRetryCountEnv     = "RABBITMQ_PUBLISH_RETRY_COUNT"
+	PublishRetryBackoffEnv   = "RABBITMQ_PUBLISH_RETRY_BACKOFF_MS"
+	MaxRedeliveriesEnv       = "RABBITMQ_MAX_REDELIVERIES"
+	DefaultPublishRetryCount = 3
+	DefaultPublishBackoffMS  = 200
+	DefaultMaxRedeliveries   = 3
+
+	maxPublishBackoff = 10 * time.Second
+)
+
+// Headers set on dead-lettered messages.
+const (
+	FailureReasonHeader = "x-iris-failure-reason"
+	FailedAtHeader      = "x-iris-failed-at"
+	DeliveryCountHeader = "x-delivery-count"
+)
+
+type retryConfig struct {
+	// publishRetries is how many times a failed publish is retried.
+	publishRetries int
+	publishBackoff time.Duration
+	// maxRedeliveries is how often a message may come back from the broker
+	// before it is quarantined as poison.
+	maxRedeliveries int
+}
+
+func retryConfigFromEnv() (retryConfig, error) {
+	retries, err := utils.GetenvNonNegativeInt(PublishRetryCountEnv, DefaultPublishRetryCount)
+	if err != nil {
+		return retryConfig{}, err
+	}
+	backoffMS, err := utils.GetenvNonNegativeInt(PublishRetryBackoffEnv, DefaultPublishBackoffMS)
+	if err != nil {
+		return retryConfig{}, err
+	}
+	redeliveries, err := utils.GetenvNonNegativeInt(MaxRedeliveriesEnv, DefaultMaxRedeliveries)
+	if err != nil {
+		return retryConfig{}, err
+	}
+	return retryConfig{
+		publishRetries:  retries,
+		publishBackoff:  time.Duration(backoffMS) * time.Millisecond,
+		maxRedeliveries: redeliveries,
+	}, nil
+}
+
+func defaultRetryConfig() retryConfig {
+	return retryConfig{
+		publishRetries:  DefaultPublishRetryCount,
+		publishBackoff:  time.Duration(DefaultPublishBackoffMS) * time.Millisecond,
+		maxRedeliveries: DefaultMaxRedeliveries,
+	}
+}
+
+// backoff doubles the delay on every retry, up to maxPublishBackoff.
+func (r retryConfig) backoff(retry int) time.Duration {
+	delay := r.publishBackoff
+	for range retry {
+		delay *= 2
+		if delay >= maxPublishBackoff {
+			return maxPublishBackoff
+		}
+	}
+	return delay
+}
+
+// withRetry calls publish until it succeeds, retrying with backoff. It gives
+// up early if ctx is done or retrying cannot help.
+func (r retryConfig) withRetry(ctx context.Context, publish func() error) error {
+	err := publish()
+	for retry := 0; err != nil && retry < r.publishRetries; retry++ {
+		if errors.Is(err, errNoDeadLetterExchange) {
+			return err
+		}
+		select {
+		case <-time.After(r.backoff(retry)):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (retry aborted: %w)", err, ctx.Err())
+		}
+		err = publish()
+	}
+	return err
+}
+
+// deliveryCount returns how many times the message was delivered before.
+// Quorum queues count this in the x-delivery-count header; other queues only
+// tell whether the message was redelivered at all.
+func deliveryCount(message amqp.Delivery) int {
+	switch count := message.Headers[DeliveryCountHeader].(type) {
+	case int:
+		return count
+	case int16:
+		return int(count)
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	}
+	if message.Redelivered {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+// Package stream routes newline-delimited JSON messages read from a reader
+// and writes every response back as newline-delimited JSON. It backs the file
+// and console connectors.
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/router"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+)
+
+// maxLineSize bounds one message, which carries the whole source code and
+// possibly user testcases.
+const maxLineSize = 64 * 1024 * 1024
+
+// Message is one input line. Type and Id play the roles of the AMQP type and
+// message_id properties, Body is the message body.
+type Message struct {
+	Type constants.MessageType `json:"type"`
+	Id   string                `json:"id"`
+	Body json.RawMessage       `json:"body"`
+}
+
+// Result is one output line, holding a response.Response of message Id.
+type Result struct {
+	Id      string                `json:"id"`
+	Type    constants.MessageType `json:"type"`
+	Message json.RawMessage       `json:"message"`
+}
+
+// Process routes the messages of in one after another, so that the results
+// of a message are written to out before the next message starts. It returns
+// when in is exhausted, ctx is done, or writing fails.
+func Process(ctx context.Context, in io.Reader, out io.Writer, router router.Router, logProvider logger.Logger) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			logProvider.Log(logger.ERROR, fmt.Sprintf("invalid message on line %d: %s", line, err))
+			if err := encoder.Encode(errorResult("", constants.Default, fmt.Errorf("invalid message on line %d: %w", line, err))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for result := range route(ctx, message, router) {
+			if err := encoder.Encode(Result{Id: message.Id, Type: result.Type, Message: result.Message}); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func route(ctx context.Context, message Message, router router.Router) <-chan response.Response {
+	resultChan := make(chan response.Response, 1)
+	if message.Type == "" {
+		resultChan <- toResponse(errorResult(message.Id, constants.Default, fmt.Errorf("type must not be empty")))
+		close(resultChan)
+	} else if message.Id == "" {
+		resultChan <- toResponse(errorResult("", message.Type, fmt.Errorf("id must not be empty")))
+		close(resultChan)
+	} else {
+		go func() {
+			defer close(resultChan)
+			router.Route(message.Type, message.Id, message.Body, resultChan, ctx)
+		}()
+	}
+	return resultChan
+}
+
+func errorResult(id string, messageType constants.MessageType, err error) Result {
+	return Result{Id: id, Type: messageType, Message: response.NewJudgeResponse(id, nil, err).Marshal()}
+}
+
+func toResponse(result Result) response.Response {
+	return response.Response{Message: result.Message, Type: result.Type}
+}
@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+// echoRouter answers with the message body, once per type.
+type echoRouter struct{}
+
+func (echoRouter) Route(path constants.MessageType, id string, data []byte, out chan<- response.Response, ctx context.Context) {
+	out <- response.Response{Message: data, Type: path}
+	out <- response.Response{Message: []byte(`{"submissionId":"` + id + `"}`), Type: constants.Submission}
+}
+
+func readResults(t *testing.T, out *bytes.Buffer) []Result {
+	var results []Result
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var result Result
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("routes messages in order", func(t *testing.T) {
+		in := strings.NewReader(
+			`{"type": "judge", "id": "1", "body": {"code": "a"}}` + "\n" +
+				"\n" +
+				`{"type": "run", "id": "2", "body": {"code": "b"}}` + "\n",
+		)
+		var out bytes.Buffer
+
+		err := Process(context.Background(), in, &out, echoRouter{}, noopLogger{})
+		require.NoError(t, err)
+
+		results := readResults(t, &out)
+		require.Len(t, results, 4)
+		assert.Equal(t, Result{Id: "1", Type: constants.Judge, Message: json.RawMessage(`{"code":"a"}`)}, results[0])
+		assert.Equal(t, constants.Submission, results[1].Type)
+		assert.Equal(t, "2", results[2].Id)
+		assert.Equal(t, constants.Run, results[2].Type)
+	})
+
+	t.Run("reports invalid messages and continues", func(t *testing.T) {
+		in := strings.NewReader(
+			"{\n" +
+				`{"type": "judge", "body": {}}` + "\n" +
+				`{"id": "3", "body": {}}` + "\n" +
+				`{"type": "judge", "id": "4", "body": {}}` + "\n",
+		)
+		var out bytes.Buffer
+
+		err := Process(context.Background(), in, &out, echoRouter{}, noopLogger{})
+		require.NoError(t, err)
+
+		results := readResults(t, &out)
+		require.Len(t, results, 5)
+		for _, result := range results[:3] {
+			assert.Contains(t, string(result.Message), `"error"`)
+		}
+		assert.Equal(t, constants.Default, results[0].Type)
+		assert.Equal(t, constants.Judge, results[1].Type)
+		assert.Equal(t, "3", results[2].Id)
+		assert.Equal(t, "4", results[3].Id)
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := Process(ctx, strings.NewReader(`{"type": "judge", "id": "1", "body": {}}`), &bytes.Buffer{}, echoRouter{}, noopLogger{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	JudgeMaxTasksEnv    = "JUDGE_MAX_TASKS"
+	GenerateMaxTasksEnv = "GENERATE_MAX_TASKS"
+	ValidateMaxTasksEnv = "VALIDATE_MAX_TASKS"
+	CheckMaxTasksEnv    = "CHECK_MAX_TASKS"
+	// Tool tasks are only admitted while the host has headroom left.
+	ToolMaxCpuPercentEnv     = "TOOL_ADMISSION_MAX_CPU_PERCENT"
+	ToolMinFreeMemoryEnv     = "TOOL_ADMISSION_MIN_FREE_MEMORY_MB"
+	DefaultToolMaxTasks      = 1
+	DefaultToolMaxCpuPercent = 85
+	DefaultToolMinFreeMemory = 512
+
+	admissionPollInterval = 200 * time.Millisecond
+)
+
+// TaskClass groups message types that share a concurrency limit.
+type TaskClass string
+
+const (
+	JudgeClass    TaskClass = "judge"
+	GenerateClass TaskClass = "generate"
+	ValidateClass TaskClass = "validate"
+	CheckClass    TaskClass = "check"
+)
+
+func TaskClassOf(messageType constants.MessageType) TaskClass {
+	switch messageType {
+	case constants.Generate:
+		return GenerateClass
+	case constants.Validate:
+		return ValidateClass
+	case constants.Check:
+		return CheckClass
+	default:
+		return JudgeClass
+	}
+}
+
+// HostMonitor reports the load of the host iris runs on.
+type HostMonitor interface {
+	// CpuPercent returns the CPU usage since the previous call.
+	CpuPercent() (float64, error)
+	AvailableMemory() (uint64, error)
+}
+
+type hostMonitor struct{}
+
+func (hostMonitor) CpuPercent() (float64, error) {
+	percent, err := cpu.Percent(0, false)
+	if err != nil || len(percent) == 0 {
+		return 0, fmt.Errorf("reading cpu usage: %v", err)
+	}
+	return percent[0], nil
+}
+
+func (hostMonitor) AvailableMemory() (uint64, error) {
+	stat, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, fmt.Errorf("reading memory usage: %w", err)
+	}
+	return stat.Available, nil
+}
+
+// Admission bounds how many tasks of each class run at once. Judge tasks only
+// wait for a free slot, while tool tasks additionally wait for the host to
+// have CPU and memory to spare, so that polygon tool jobs cannot starve
+// judging on the same host. Waiting tasks are served by the weight of their
+// lane (see WithLane), and in the order they were delivered within a lane, so
+// priority queues of the broker keep their order. Lanes only compete once the
+// slots of a class are taken, see PrefetchCount. A nil *Admission admits
+// everything.
+type Admission struct {
+	slots         map[TaskClass]*semaphore
+	host          HostMonitor
+	maxCpuPercent float64
+	minFreeMemory uint64
+}
+
+// NewAdmission limits each class to the given number of concurrent tasks.
+// Classes without a limit are not bounded, and lanes without a weight have
+// weight 1. A zero maxCpuPercent or minFreeMemory disables the respective
+// host check.
+func NewAdmission(limits map[TaskClass]int, weights map[string]int, host HostMonitor, maxCpuPercent float64, minFreeMemory uint64) *Admission {
+	slots := make(map[TaskClass]*semaphore, len(limits))
+	for class, limit := range limits {
+		slots[class] = newSemaphore(limit, weights)
+	}
+	return &Admission{
+		slots:         slots,
+		host:          host,
+		maxCpuPercent: maxCpuPercent,
+		minFreeMemory: minFreeMemory,
+	}
+}
+
+// AdmissionFromEnv allows as many judge tasks as there are CPUs by default.
+func AdmissionFromEnv(lanes []Lane) (*Admission, error) {
+	limits := map[TaskClass]int{}
+	for class, env := range map[TaskClass]string{
+		JudgeClass:    JudgeMaxTasksEnv,
+		GenerateClass: GenerateMaxTasksEnv,
+		ValidateClass: ValidateMaxTasksEnv,
+		CheckClass:    CheckMaxTasksEnv,
+	} {
+		fallback := DefaultToolMaxTasks
+		if class == JudgeClass {
+			fallback = runtime.NumCPU()
+		}
+		limit, err := utils.GetenvPositiveInt(env, fallback)
+		if err != nil {
+			return nil, err
+		}
+		limits[class] = limit
+	}
+
+	maxCpuPercent, err := utils.GetenvNonNegativeInt(ToolMaxCpuPercentEnv, DefaultToolMaxCpuPercent)
+	if err != nil {
+		return nil, err
+	}
+	minFreeMemory, err := utils.GetenvNonNegativeInt(ToolMinFreeMemoryEnv, DefaultToolMinFreeMemory)
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[string]int, len(lanes))
+	for _, lane := range lanes {
+		weights[lane.Name] = lane.Weight
+	}
+	return NewAdmission(limits, weights, hostMonitor{}, float64(maxCpuPercent), uint64(minFreeMemory)*1024*1024), nil
+}
+
+// Acquire blocks until a task of class may start. The returned release must
+// be called once the task is done.
+func (a *Admission) Acquire(ctx context.Context, class TaskClass) (release func(), err error) {
+	if a == nil {
+		return func() {}, nil
+	}
+
+	slots, ok := a.slots[class]
+	if !ok {
+		return func() {}, nil
+	}
+	if err := slots.acquire(ctx, LaneFrom(ctx)); err != nil {
+		return nil, err
+	}
+	release = slots.release
+
+	if class == JudgeClass {
+		return release, nil
+	}
+	for !a.hasHeadroom() {
+		select {
+		case <-time.After(admissionPollInterval):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// Limit returns the number of tasks of class that may run at once, or 0 if
+// they are not bounded.
+func (a *Admission) Limit(class TaskClass) int {
+	if a == nil {
+		return 0
+	}
+	if slots, ok := a.slots[class]; ok {
+		return slots.size
+	}
+	return 0
+}
+
+// hasHeadroom reports whether the host can take another tool task. A host
+// that cannot be measured is assumed to have headroom.
+func (a *Admission) hasHeadroom() bool {
+	if a.host == nil {
+		return true
+	}
+	if a.maxCpuPercent > 0 {
+		if percent, err := a.host.CpuPercent(); err == nil && percent > a.maxCpuPercent {
+			return false
+		}
+	}
+	if a.minFreeMemory > 0 {
+		if available, err := a.host.AvailableMemory(); err == nil && available < a.minFreeMemory {
+			return false
+		}
+	}
+	return true
+}
+
+// semaphore hands out a fixed number of slots. Once they are taken, waiters
+// queue per lane and freed slots go to the lanes by smooth weighted round
+// robin, first come first served within a lane.
+type semaphore struct {
+	size    int
+	mu      sync.Mutex
+	free    int
+	weights map[string]int
+	waiters map[string][]chan struct{}
+	current map[string]int
+}
+
+func newSemaphore(size int, weights map[string]int) *semaphore {
+	return &semaphore{
+		size:    size,
+		free:    size,
+		weights: weights,
+		waiters: map[string][]chan struct{}{},
+		current: map[string]int{},
+	}
+}
+
+func (s *semaphore) acquire(ctx context.Context, lane string) error {
+	s.mu.Lock()
+	if s.free > 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.waiters[lane] = append(s.waiters[lane], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-ready:
+			// 취소와 동시에 slot을 받았으면 다음 대기자에게 넘김
+			s.releaseLocked()
+		default:
+			s.removeLocked(lane, ready)
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *semaphore) releaseLocked() {
+	lane, ok := s.nextLaneLocked()
+	if !ok {
+		s.free++
+		return
+	}
+	ready := s.waiters[lane][0]
+	s.waiters[lane] = s.waiters[lane][1:]
+	close(ready)
+}
+
+func (s *semaphore) removeLocked(lane string, ready chan struct{}) {
+	queue := s.waiters[lane]
+	for i, waiter := range queue {
+		if waiter == ready {
+			s.waiters[lane] = append(queue[:i:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// nextLaneLocked picks the lane to serve next among those with waiters, as
+// in nginx's smooth weighted round robin.
+func (s *semaphore) nextLaneLocked() (string, bool) {
+	var best string
+	found := false
+	total := 0
+	for lane, queue := range s.waiters {
+		if len(queue) == 0 {
+			continue
+		}
+		weight := s.weights[lane]
+		if weight <= 0 {
+			weight = 1
+		}
+		s.current[lane] += weight
+		total += weight
+		if !found || s.current[lane] > s.current[best] || (s.current[lane] == s.current[best] && lane < best) {
+			best, found = lane, true
+		}
+	}
+	if found {
+		s.current[best] -= total
+	}
+	return best, found
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHost struct {
+	cpuPercent atomic.Int64
+	available  atomic.Uint64
+}
+
+func (h *fakeHost) CpuPercent() (float64, error)     { return float64(h.cpuPercent.Load()), nil }
+func (h *fakeHost) AvailableMemory() (uint64, error) { return h.available.Load(), nil }
+
+func TestAdmission(t *testing.T) {
+	t.Run("bounds tasks per class", func(t *testing.T) {
+		admission := NewAdmission(map[TaskClass]int{JudgeClass: 1, GenerateClass: 1}, nil, nil, 0, 0)
+
+		release, err := admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+
+		// 다른 종류의 task는 영향을 받지 않음
+		releaseGenerate, err := admission.Acquire(context.Background(), GenerateClass)
+		require.NoError(t, err)
+		releaseGenerate()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = admission.Acquire(ctx, JudgeClass)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		release()
+		release, err = admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("holds tool tasks back while the host is busy", func(t *testing.T) {
+		host := &fakeHost{}
+		host.cpuPercent.Store(95)
+		host.available.Store(1 << 30)
+		admission := NewAdmission(map[TaskClass]int{JudgeClass: 1, ValidateClass: 1}, nil, host, 85, 1<<20)
+
+		release, err := admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+		release()
+
+		admitted := make(chan struct{})
+		go func() {
+			release, err := admission.Acquire(context.Background(), ValidateClass)
+			if err == nil {
+				release()
+			}
+			close(admitted)
+		}()
+
+		select {
+		case <-admitted:
+			t.Fatal("tool task admitted on a busy host")
+		case <-time.After(50 * time.Millisecond):
+		}
+		host.cpuPercent.Store(10)
+		<-admitted
+	})
+
+	t.Run("holds tool tasks back while memory is low", func(t *testing.T) {
+		host := &fakeHost{}
+		host.available.Store(1 << 20)
+		admission := NewAdmission(map[TaskClass]int{CheckClass: 1}, nil, host, 0, 1<<30)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := admission.Acquire(ctx, CheckClass)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// 실패한 요청의 slot은 반환되어야 함
+		host.available.Store(1 << 31)
+		release, err := admission.Acquire(context.Background(), CheckClass)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("serves waiting lanes by weight", func(t *testing.T) {
+		admission := NewAdmission(map[TaskClass]int{JudgeClass: 1}, map[string]int{"contest": 3, "practice": 1}, nil, 0, 0)
+		release, err := admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var order []string
+		var wg sync.WaitGroup
+		for _, lane := range []string{"practice", "practice", "contest", "contest", "contest", "contest"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := admission.Acquire(WithLane(context.Background(), lane), JudgeClass)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				order = append(order, lane)
+				mu.Unlock()
+				release()
+			}()
+		}
+
+		waiting := func() bool {
+			slots := admission.slots[JudgeClass]
+			slots.mu.Lock()
+			defer slots.mu.Unlock()
+			return len(slots.waiters["contest"])+len(slots.waiters["practice"]) == 6
+		}
+		require.Eventually(t, waiting, time.Second, time.Millisecond)
+
+		release()
+		wg.Wait()
+		assert.Equal(t, []string{"contest", "contest", "practice", "contest"}, order[:4])
+	})
+
+	t.Run("lanes compete for slots at the default prefetch", func(t *testing.T) {
+		t.Setenv(JudgeMaxTasksEnv, "")
+		lanes := []Lane{{Name: "contest", Weight: 3}, {Name: "practice", Weight: 1}}
+		admission, err := AdmissionFromEnv(lanes)
+		require.NoError(t, err)
+		limit := admission.Limit(JudgeClass)
+		prefetch := PrefetchCount(lanes, admission)
+
+		// practice was delivered first and runs on every slot
+		practice := WithLane(context.Background(), "practice")
+		var releases []func()
+		for range limit {
+			release, err := admission.Acquire(practice, JudgeClass)
+			require.NoError(t, err)
+			releases = append(releases, release)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		served := make(chan string, 2*prefetch)
+		wait := func(lane string, count int) {
+			for range count {
+				go func() {
+					release, err := admission.Acquire(WithLane(ctx, lane), JudgeClass)
+					if err != nil {
+						return
+					}
+					served <- lane
+					<-ctx.Done()
+					release()
+				}()
+			}
+			require.Eventually(t, func() bool {
+				slots := admission.slots[JudgeClass]
+				slots.mu.Lock()
+				defer slots.mu.Unlock()
+				return len(slots.waiters[lane]) == count
+			}, time.Second, time.Millisecond)
+		}
+		wait("practice", prefetch-limit)
+		wait("contest", prefetch)
+
+		releases[0]()
+		assert.Equal(t, "contest", <-served, "the heavier lane goes first although practice waited longer")
+		for _, release := range releases[1:] {
+			release()
+		}
+	})
+
+	t.Run("cancelled waiters leave the queue", func(t *testing.T) {
+		admission := NewAdmission(map[TaskClass]int{JudgeClass: 1}, nil, nil, 0, 0)
+		release, err := admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(WithLane(context.Background(), "practice"), 10*time.Millisecond)
+		defer cancel()
+		_, err = admission.Acquire(ctx, JudgeClass)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		release()
+		release, err = admission.Acquire(context.Background(), JudgeClass)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("nil admits everything", func(t *testing.T) {
+		var admission *Admission
+		release, err := admission.Acquire(context.Background(), GenerateClass)
+		require.NoError(t, err)
+		release()
+	})
+}
+
+func TestTaskClassOf(t *testing.T) {
+	assert.Equal(t, JudgeClass, TaskClassOf(constants.SpecialJudge))
+	assert.Equal(t, JudgeClass, TaskClassOf(constants.UserTestCase))
+	assert.Equal(t, GenerateClass, TaskClassOf(constants.Generate))
+	assert.Equal(t, ValidateClass, TaskClassOf(constants.Validate))
+	assert.Equal(t, CheckClass, TaskClassOf(constants.Check))
+}
+
+func TestAdmissionFromEnv(t *testing.T) {
+	t.Setenv(GenerateMaxTasksEnv, "0")
+	_, err := AdmissionFromEnv(nil)
+	assert.EqualError(t, err, "GENERATE_MAX_TASKS must be a positive integer")
+}
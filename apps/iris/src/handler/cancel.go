@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// ErrCanceledByRequest is the cause of contexts canceled by Cancellations.
+var ErrCanceledByRequest = errors.New("canceled by request")
+
+// CancelRequest is the body of a cancel message.
+type CancelRequest struct {
+	SubmissionId int `json:"submissionId"`
+}
+
+// MessageId returns the message id the tasks of the submission run under.
+func (r CancelRequest) MessageId() string {
+	return strconv.Itoa(r.SubmissionId)
+}
+
+// Cancellations maps the message ids of running tasks to their contexts, so
+// that a cancel message can abort them. A message id may be running more
+// than once, e.g. while a submission is rejudged.
+type Cancellations struct {
+	mu      sync.Mutex
+	next    uint64
+	cancels map[string]map[uint64]context.CancelCauseFunc
+}
+
+func NewCancellations() *Cancellations {
+	return &Cancellations{cancels: map[string]map[uint64]context.CancelCauseFunc{}}
+}
+
+// Register returns a context derived from ctx that Cancel(id) cancels with
+// ErrCanceledByRequest. done must be called once the task is over.
+func (c *Cancellations) Register(ctx context.Context, id string) (taskCtx context.Context, done func()) {
+	taskCtx, cancel := context.WithCancelCause(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := c.next
+	c.next++
+	if c.cancels[id] == nil {
+		c.cancels[id] = map[uint64]context.CancelCauseFunc{}
+	}
+	c.cancels[id][key] = cancel
+
+	return taskCtx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.cancels[id], key)
+		if len(c.cancels[id]) == 0 {
+			delete(c.cancels, id)
+		}
+		cancel(nil)
+	}
+}
+
+// Cancel cancels every running task of id and reports whether there was any.
+func (c *Cancellations) Cancel(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cancel := range c.cancels[id] {
+		cancel(ErrCanceledByRequest)
+	}
+	return len(c.cancels[id]) > 0
+}
+
+// CanceledByRequest reports whether ctx was canceled by Cancellations.
+func CanceledByRequest(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrCanceledByRequest)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancellations(t *testing.T) {
+	t.Run("cancels every task of an id", func(t *testing.T) {
+		cancellations := NewCancellations()
+		first, doneFirst := cancellations.Register(context.Background(), "1")
+		defer doneFirst()
+		second, doneSecond := cancellations.Register(context.Background(), "1")
+		defer doneSecond()
+		other, doneOther := cancellations.Register(context.Background(), "2")
+		defer doneOther()
+
+		assert.True(t, cancellations.Cancel("1"))
+		assert.True(t, CanceledByRequest(first))
+		assert.True(t, CanceledByRequest(second))
+		assert.NoError(t, other.Err())
+	})
+
+	t.Run("forgets finished tasks", func(t *testing.T) {
+		cancellations := NewCancellations()
+		ctx, done := cancellations.Register(context.Background(), "1")
+		done()
+
+		assert.False(t, cancellations.Cancel("1"))
+		assert.False(t, CanceledByRequest(ctx))
+		assert.Empty(t, cancellations.cancels)
+	})
+
+	t.Run("does not mistake other cancellations", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		ctx, done := NewCancellations().Register(parent, "1")
+		defer done()
+
+		cancel()
+		assert.Error(t, ctx.Err())
+		assert.False(t, CanceledByRequest(ctx))
+	})
+}
@@ -0,0 +1,91 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckValidate(t *testing.T) {
+	t.Run("invalid problemId", func(t *testing.T) {
+		t.Parallel()
+		req := CheckRequest{
+			Language:    "Cpp",
+			CheckerCode: "int main(){}",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "problemId must not be empty or zero")
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		t.Parallel()
+		req := CheckRequest{
+			ProblemId:   1,
+			CheckerCode: "int main(){}",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "language must not be empty")
+	})
+
+	t.Run("invalid checkerCode", func(t *testing.T) {
+		t.Parallel()
+		req := CheckRequest{
+			ProblemId: 1,
+			Language:  "Cpp",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "checkerCode must not be empty")
+	})
+
+	t.Run("solution code without language", func(t *testing.T) {
+		t.Parallel()
+		req := CheckRequest{
+			ProblemId:    1,
+			Language:     "Cpp",
+			CheckerCode:  "int main(){}",
+			SolutionCode: "int main(){}",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "solutionLanguage must not be empty when solutionCode is provided")
+	})
+
+	t.Run("valid request with solution", func(t *testing.T) {
+		t.Parallel()
+		req := CheckRequest{
+			ProblemId:        1,
+			Language:         "Cpp",
+			CheckerCode:      "int main(){}",
+			SolutionLanguage: "Cpp",
+			SolutionCode:     "int main(){}",
+		}
+		result, err := req.Validate()
+
+		assert.NotNil(t, result)
+		assert.Nil(t, err)
+	})
+}
+
+func TestCheckFactoryCreate(t *testing.T) {
+	t.Run("adds a solution unit only when solution code is given", func(t *testing.T) {
+		t.Parallel()
+		factory := NewFactory(nil, nil, nil, nil)
+
+		task, err := factory.Create("check", []byte(`{"problemId":1,"language":"Cpp","checkerCode":"int main(){}"}`))
+		assert.Nil(t, err)
+		assert.Len(t, task.GetBuildUnits(), 1)
+		assert.Equal(t, CheckerUnitName, task.GetBuildUnits()[0].Name)
+
+		task, err = factory.Create("check", []byte(`{"problemId":1,"language":"Cpp","checkerCode":"int main(){}","solutionLanguage":"C","solutionCode":"int main(){}"}`))
+		assert.Nil(t, err)
+		assert.Len(t, task.GetBuildUnits(), 2)
+		assert.Equal(t, SolutionUnitName, task.GetBuildUnits()[1].Name)
+	})
+}
@@ -0,0 +1,69 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
+)
+
+type Factory struct {
+	tcManager testcase.TestcaseReader
+	sandbox   sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file      file.FileManager
+	logger    logger.Logger
+}
+
+func NewFactory(tcManager testcase.TestcaseReader, sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs], file file.FileManager, logger logger.Logger) *Factory {
+	return &Factory{
+		tcManager: tcManager,
+		sandbox:   sandbox,
+		file:      file,
+		logger:    logger,
+	}
+}
+
+func (f *Factory) Create(taskType string, data []byte) (handler.Task, error) {
+	req := CheckRequest{}
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("unmarshal failed: %w", err))
+	}
+
+	validReq, err := req.Validate()
+	if err != nil {
+		return nil, handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("validation failed: %w", err))
+	}
+
+	buildUnits := []*build.BuildUnit{
+		{
+			Name:     CheckerUnitName,
+			Code:     validReq.CheckerCode,
+			Language: validReq.Language,
+		},
+	}
+	if validReq.SolutionCode != "" {
+		buildUnits = append(buildUnits, &build.BuildUnit{
+			Name:     SolutionUnitName,
+			Code:     validReq.SolutionCode,
+			Language: validReq.SolutionLanguage,
+		})
+	}
+
+	task := &Task{
+		req:        validReq,
+		buildUnits: buildUnits,
+		tcManager:  f.tcManager,
+		sandbox:    f.sandbox,
+		file:       f.file,
+		logger:     f.logger,
+	}
+
+	return task, nil
+}
@@ -6,6 +6,11 @@ import (
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
 )
 
+const (
+	CheckerUnitName  = "checker"
+	SolutionUnitName = "solution"
+)
+
 type CheckRequest struct {
 	ProblemId        int    `json:"problemId"`
 	Language         string `json:"language"`
@@ -0,0 +1,240 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/checker"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
+)
+
+type Task struct {
+	req        *CheckRequest
+	buildUnits []*build.BuildUnit
+	tcManager  testcase.TestcaseReader
+	sandbox    sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file       file.FileManager
+	logger     logger.Logger
+}
+
+func (t *Task) GetDebugString() string {
+	if t == nil {
+		return "check.Task<nil>"
+	}
+	if t.req == nil {
+		return "check.Task{req:nil}"
+	}
+	return fmt.Sprintf("check.Task{problemId:%d,language:%s}", t.req.ProblemId, t.req.Language)
+}
+
+func (t *Task) GetBuildUnits() []*build.BuildUnit {
+	return t.buildUnits
+}
+
+func (t *Task) RunAction(ctx context.Context, _ string, resultSender handler.ResultSender) {
+	validReq := t.req
+
+	var checkerUnit *build.BuildUnit
+	var solutionUnit *build.BuildUnit
+	for _, u := range t.buildUnits {
+		switch u.Name {
+		case CheckerUnitName:
+			checkerUnit = u
+		case SolutionUnitName:
+			solutionUnit = u
+		}
+	}
+	if checkerUnit == nil || checkerUnit.Dir == "" {
+		resultSender(handler.ResultMessage{
+			Result: nil,
+			Err:    handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("checker build unit not found")),
+		})
+		return
+	}
+
+	tc, err := t.tcManager.GetTestcase(ctx, strconv.Itoa(validReq.ProblemId), testcase.ALL)
+	if err != nil {
+		resultSender(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("check", handler.TESTCASE_ERROR, logger.ERROR, fmt.Errorf("get testcase failed: %w", err))})
+		return
+	}
+
+	limits, err := handler.ToolLimitsFromEnv()
+	if err != nil {
+		resultSender(handler.ResultMessage{
+			Result: nil,
+			Err:    handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, err),
+		})
+		return
+	}
+
+	allCorrect, results, err := t.runChecks(ctx, checker.NewChecker(checkerUnit, t.sandbox, t.file), solutionUnit, tc.Elements, limits)
+	if err != nil {
+		resultSender(handler.ResultMessage{
+			Result: nil,
+			Err:    handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, err),
+		})
+		return
+	}
+
+	res := CheckToolResult{
+		IsAllCorrect:  allCorrect,
+		TestcaseCount: len(tc.Elements),
+		Results:       results,
+	}
+	marshaledRes, err := json.Marshal(res)
+	if err != nil {
+		resultSender(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("check", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("marshal failed"))})
+	} else {
+		resultSender(handler.ResultMessage{Result: marshaledRes, Err: nil})
+	}
+}
+
+func (t *Task) runChecks(
+	ctx context.Context,
+	testlibChecker *checker.Checker,
+	solutionUnit *build.BuildUnit,
+	elements []loader.ElementOut,
+	limits handler.ToolExecutionLimits,
+) (bool, []CheckTestcaseToolResult, error) {
+	workerCount, err := handler.WorkerCountFromEnv("CHECK_CONCURRENCY", len(elements), 1)
+	if err != nil {
+		return false, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, nil, fmt.Errorf("check cancelled: %w", err)
+	}
+
+	// This function owns jobs: it creates, sends to, and closes the channel.
+	jobs := make(chan int)
+	results := make([]CheckTestcaseToolResult, len(elements))
+	errs := make([]error, len(elements))
+	var wg sync.WaitGroup
+
+	worker := func() {
+		defer wg.Done()
+		for i := range jobs {
+			results[i], errs[i] = t.checkTestcase(i, elements[i], testlibChecker, solutionUnit, limits)
+		}
+	}
+
+	wg.Add(workerCount)
+	for range workerCount {
+		go worker()
+	}
+
+schedule:
+	for i := range elements {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break schedule
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return false, nil, fmt.Errorf("check cancelled: %w", err)
+	}
+
+	for _, e := range errs {
+		if e != nil {
+			return false, nil, e
+		}
+	}
+
+	allCorrect := true
+	for _, r := range results {
+		if r.Verdict != string(checker.OK) {
+			allCorrect = false
+			break
+		}
+	}
+
+	return allCorrect, results, nil
+}
+
+func (t *Task) checkTestcase(
+	idx int,
+	element loader.ElementOut,
+	testlibChecker *checker.Checker,
+	solutionUnit *build.BuildUnit,
+	limits handler.ToolExecutionLimits,
+) (res CheckTestcaseToolResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in check TC %d: %v", idx, r)
+		}
+	}()
+
+	res.TestcaseId = element.Id
+
+	// Without a solution the stored answer is checked against itself,
+	// which still catches checkers that reject the reference output.
+	output, outputPath := []byte(element.Out), element.OutPath
+	if solutionUnit != nil {
+		solutionResult, runErr := solutionUnit.Run(t.sandbox, sandbox.RunRequest{
+			Order:        idx,
+			TimeLimit:    limits.TimeLimit,
+			MemoryLimit:  limits.MemoryLimit,
+			ExtraArgs:    []string{},
+			InputPath:    element.InPath,
+			OutputOnDisk: element.OnDisk(),
+		}, []byte(element.In))
+		if runErr != nil {
+			t.logger.Log(logger.ERROR, fmt.Sprintf("Error while running solution: %s", runErr.Error()))
+			return res, runErr
+		}
+		res.CpuTime = solutionResult.ExecResult.CpuTime
+		res.Memory = solutionResult.ExecResult.Memory
+		if solutionResult.ExecResult.StatusCode != sandbox.RUN_SUCCESS {
+			t.logger.Log(logger.INFO, fmt.Sprintf("Solution execution failed at testcase %d", idx))
+			res.Verdict = string(checker.FAIL)
+			res.Message = fmt.Sprintf("solution execution failed, status: %v", solutionResult.ExecResult.StatusCode)
+			return res, nil
+		}
+		output, outputPath = solutionResult.Output, solutionResult.OutputPath
+	}
+
+	req := sandbox.RunRequest{
+		Order:       idx,
+		TimeLimit:   limits.TimeLimit,
+		MemoryLimit: limits.MemoryLimit,
+	}
+	var checkResult checker.Result
+	var checkErr error
+	if element.OnDisk() {
+		if outputPath == "" {
+			outputPath, checkErr = testlibChecker.Stage(idx, "out", output)
+		}
+		if checkErr == nil {
+			checkResult, checkErr = testlibChecker.CheckTestcase(req, element, outputPath)
+		}
+	} else {
+		checkResult, checkErr = testlibChecker.Check(req, []byte(element.In), output, []byte(element.Out))
+	}
+	if checkErr != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Error while checking testcase: %s", checkErr.Error()))
+		return res, checkErr
+	}
+	if solutionUnit == nil {
+		res.CpuTime = checkResult.ExecResult.CpuTime
+		res.Memory = checkResult.ExecResult.Memory
+	}
+	if checkResult.Verdict != checker.OK {
+		t.logger.Log(logger.INFO, fmt.Sprintf("Check failed at testcase %d: %s", idx, checkResult.Verdict))
+	}
+
+	res.Verdict = string(checkResult.Verdict)
+	res.Message = checkResult.Message
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	JudgeConcurrencyEnv     = "JUDGE_CONCURRENCY"
+	JudgeCpusEnv            = "JUDGE_CPUS"
+	DefaultJudgeConcurrency = 1
+)
+
+// JudgeWorkerCountFromEnv returns how many testcases of one submission may
+// run at once, capped to the number of testcases.
+func JudgeWorkerCountFromEnv(total int) (int, error) {
+	if total <= 0 {
+		return 0, nil
+	}
+	workers, err := utils.GetenvPositiveInt(JudgeConcurrencyEnv, DefaultJudgeConcurrency)
+	if err != nil {
+		return 0, err
+	}
+	return min(workers, total), nil
+}
+
+// CpuPool hands out dedicated CPUs to solution runs, so that testcases
+// running at the same time, across all submissions, never share a core and
+// their CPU times stay comparable. A nil *CpuPool disables pinning.
+type CpuPool struct {
+	cpus chan int
+}
+
+func NewCpuPool(cpus []int) *CpuPool {
+	pool := &CpuPool{cpus: make(chan int, len(cpus))}
+	for _, cpu := range cpus {
+		pool.cpus <- cpu
+	}
+	return pool
+}
+
+// CpuPoolFromEnv builds the pool from a CPU list such as "2-5,7".
+// It returns nil if the variable is unset.
+func CpuPoolFromEnv() (*CpuPool, error) {
+	raw := os.Getenv(JudgeCpusEnv)
+	if raw == "" {
+		return nil, nil
+	}
+	cpus, err := parseCpuList(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a CPU list like \"0-3,6\": %w", JudgeCpusEnv, err)
+	}
+	return NewCpuPool(cpus), nil
+}
+
+// Acquire blocks until a CPU is free and returns it as a CPU set for
+// sandbox.RunRequest. It returns a nil set if p is nil.
+func (p *CpuPool) Acquire(ctx context.Context) ([]int, error) {
+	if p == nil {
+		return nil, nil
+	}
+	select {
+	case cpu := <-p.cpus:
+		return []int{cpu}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *CpuPool) Release(cpuSet []int) {
+	if p == nil {
+		return
+	}
+	for _, cpu := range cpuSet {
+		p.cpus <- cpu
+	}
+}
+
+func parseCpuList(raw string) ([]int, error) {
+	var cpus []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid cpu %q", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid cpu range %q", part)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			if seen[cpu] {
+				return nil, fmt.Errorf("duplicate cpu %d", cpu)
+			}
+			seen[cpu] = true
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
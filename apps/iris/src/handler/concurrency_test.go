@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJudgeWorkerCountFromEnv(t *testing.T) {
+	t.Run("runs sequentially by default", func(t *testing.T) {
+		t.Setenv(JudgeConcurrencyEnv, "")
+		workers, err := JudgeWorkerCountFromEnv(10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, workers)
+	})
+
+	t.Run("caps to testcase count", func(t *testing.T) {
+		t.Setenv(JudgeConcurrencyEnv, "8")
+		workers, err := JudgeWorkerCountFromEnv(3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, workers)
+	})
+
+	t.Run("rejects invalid count", func(t *testing.T) {
+		t.Setenv(JudgeConcurrencyEnv, "0")
+		_, err := JudgeWorkerCountFromEnv(3)
+		assert.EqualError(t, err, "JUDGE_CONCURRENCY must be a positive integer")
+	})
+}
+
+func TestCpuPoolFromEnv(t *testing.T) {
+	t.Run("disabled when omitted", func(t *testing.T) {
+		t.Setenv(JudgeCpusEnv, "")
+		pool, err := CpuPoolFromEnv()
+		require.NoError(t, err)
+		assert.Nil(t, pool)
+
+		cpuSet, err := pool.Acquire(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, cpuSet)
+		pool.Release(cpuSet)
+	})
+
+	t.Run("parses lists and ranges", func(t *testing.T) {
+		t.Setenv(JudgeCpusEnv, "2-4, 7")
+		pool, err := CpuPoolFromEnv()
+		require.NoError(t, err)
+
+		var acquired []int
+		for range 4 {
+			cpuSet, err := pool.Acquire(context.Background())
+			require.NoError(t, err)
+			acquired = append(acquired, cpuSet...)
+		}
+		assert.Equal(t, []int{2, 3, 4, 7}, acquired)
+	})
+
+	t.Run("rejects invalid lists", func(t *testing.T) {
+		for _, raw := range []string{"a", "3-1", "1,1", "-1"} {
+			t.Setenv(JudgeCpusEnv, raw)
+			_, err := CpuPoolFromEnv()
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+func TestCpuPoolAcquire(t *testing.T) {
+	pool := NewCpuPool([]int{0})
+	cpuSet, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = pool.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	pool.Release(cpuSet)
+	cpuSet, err = pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, cpuSet)
+}
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
 	"github.com/skkuding/codedang/apps/iris/src/handler"
 	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/checker"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
@@ -16,14 +20,18 @@ import (
 type Factory struct {
 	tcManager testcase.TestcaseReader
 	sandbox   sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file      file.FileManager
+	cpuPool   *handler.CpuPool
 	logger    logger.Logger
 	tracer    trace.Tracer
 }
 
-func NewFactory(tcManager testcase.TestcaseReader, sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs], logger logger.Logger, tracer trace.Tracer) *Factory {
+func NewFactory(tcManager testcase.TestcaseReader, sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs], file file.FileManager, cpuPool *handler.CpuPool, logger logger.Logger, tracer trace.Tracer) *Factory {
 	return &Factory{
 		tcManager: tcManager,
 		sandbox:   sandbox,
+		file:      file,
+		cpuPool:   cpuPool,
 		logger:    logger,
 		tracer:    tracer,
 	}
@@ -36,23 +44,54 @@ func (f *Factory) Create(taskType string, data []byte) (handler.Task, error) {
 	if err != nil {
 		return nil, handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("unmarshal failed: %w", err))
 	}
+	if taskType == string(constants.Interactive) {
+		req.IsInteractive = true
+	}
 	validReq, err := req.Validate()
 	if err != nil {
 		return nil, handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("validation failed: %w", err))
 	}
+	if taskType == string(constants.SpecialJudge) && validReq.CheckerCode == "" {
+		return nil, handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("validation failed: checkerCode must not be empty for special judge"))
+	}
 
 	tcFilter := testcase.TestcaseFilterCode(testcase.ALL)
 	if validReq.JudgeOnlyHiddenTestcases {
 		tcFilter = testcase.HIDDEN_ONLY
 	}
 
+	outputGrader, err := grader.New(grader.Strategy(validReq.Grader), validReq.FloatTolerance)
+	if err != nil {
+		return nil, handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("validation failed: %w", err))
+	}
+
 	buildUnits := []*build.BuildUnit{
 		{
-			Name:     "default",
+			Name:     DefaultUnitName,
 			Code:     validReq.Code,
 			Language: validReq.Language,
 		},
 	}
+	var specialChecker *checker.Checker
+	if validReq.CheckerCode != "" {
+		checkerUnit := &build.BuildUnit{
+			Name:     CheckerUnitName,
+			Code:     validReq.CheckerCode,
+			Language: validReq.CheckerLanguage,
+		}
+		buildUnits = append(buildUnits, checkerUnit)
+		specialChecker = checker.NewChecker(checkerUnit, f.sandbox, f.file)
+	}
+	var interactor *checker.Interactor
+	if validReq.IsInteractive {
+		interactorUnit := &build.BuildUnit{
+			Name:     InteractorUnitName,
+			Code:     validReq.InteractorCode,
+			Language: validReq.InteractorLanguage,
+		}
+		buildUnits = append(buildUnits, interactorUnit)
+		interactor = checker.NewInteractor(interactorUnit, f.sandbox, f.file)
+	}
 
 	task := &Task{
 		req:        validReq,
@@ -60,8 +99,13 @@ func (f *Factory) Create(taskType string, data []byte) (handler.Task, error) {
 		buildUnits: buildUnits,
 		tcManager:  f.tcManager,
 		sandbox:    f.sandbox,
+		file:       f.file,
+		cpuPool:    f.cpuPool,
 		logger:     f.logger,
 		tracer:     f.tracer,
+		grader:     outputGrader,
+		checker:    specialChecker,
+		interactor: interactor,
 	}
 
 	return task, nil
@@ -3,6 +3,7 @@ package judge
 import (
 	"testing"
 
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -82,6 +83,85 @@ func TestValidate(t *testing.T) {
 		assert.EqualError(t, err, "memoryLimit must not be empty or less than 0")
 	})
 
+	t.Run("invalid outputLimit", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:        "print('')",
+			Language:    "C",
+			ProblemId:   1,
+			TimeLimit:   1000,
+			MemoryLimit: 1024,
+			OutputLimit: -1,
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "outputLimit must not be less than 0")
+	})
+
+	t.Run("invalid timeLimitScales", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:            "print('')",
+			Language:        "C",
+			ProblemId:       1,
+			TimeLimit:       1000,
+			MemoryLimit:     1024,
+			TimeLimitScales: map[string]float64{"Python3": 0},
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "timeLimitScales of Python3 must be greater than 0 and at most 10")
+	})
+
+	t.Run("invalid realTimeMultiplier", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:               "print('')",
+			Language:           "C",
+			ProblemId:          1,
+			TimeLimit:          1000,
+			MemoryLimit:        1024,
+			RealTimeMultiplier: 0.5,
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "realTimeMultiplier must not be less than 1")
+	})
+
+	t.Run("realTimeMultiplier too large", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:               "print('')",
+			Language:           "C",
+			ProblemId:          1,
+			TimeLimit:          1000,
+			MemoryLimit:        1024,
+			RealTimeMultiplier: 1e12,
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "realTimeMultiplier must not be greater than 10")
+	})
+
+	t.Run("timeLimitScales of an alias", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{Language: "Cpp", TimeLimitScales: map[string]float64{"Cpp14": 2}}
+
+		assert.Equal(t, 2.0, req.TimeScaling().Scale)
+	})
+
+	t.Run("timeLimitScales of the interactor", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{Language: "Python3", InteractorLanguage: "C", TimeLimitScales: map[string]float64{"Python3": 3}}
+
+		assert.Equal(t, 3.0, req.TimeScaling().Scale)
+		assert.Equal(t, 0.0, req.InteractorTimeScaling().Scale)
+	})
+
 	t.Run("valid request", func(t *testing.T) {
 		t.Parallel()
 		req := JudgeRequest{
@@ -96,4 +176,208 @@ func TestValidate(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.Nil(t, err)
 	})
+
+	t.Run("versioned and aliased languages", func(t *testing.T) {
+		t.Parallel()
+		for _, language := range []string{"Cpp", "Cpp17", "Cpp20", "Java", "Java21", "Python3", "Python3.11"} {
+			req := JudgeRequest{
+				Code:        "print('')",
+				Language:    language,
+				ProblemId:   1,
+				TimeLimit:   1000,
+				MemoryLimit: 100,
+			}
+			_, err := req.Validate()
+
+			assert.Nil(t, err, language)
+		}
+	})
+
+	t.Run("checker code without language", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:        "print('')",
+			Language:    "C",
+			ProblemId:   1,
+			TimeLimit:   1000,
+			MemoryLimit: 100,
+			CheckerCode: "int main(){}",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "checkerCode and checkerLanguage must be provided together")
+	})
+
+	t.Run("unsupported checkerLanguage", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:            "print('')",
+			Language:        "C",
+			ProblemId:       1,
+			TimeLimit:       1000,
+			MemoryLimit:     100,
+			CheckerCode:     "int main(){}",
+			CheckerLanguage: "COBOL",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "unsupported checkerLanguage: COBOL")
+	})
+
+	t.Run("unsupported grader", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:        "print('')",
+			Language:    "C",
+			ProblemId:   1,
+			TimeLimit:   1000,
+			MemoryLimit: 100,
+			Grader:      "fuzzy",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "unsupported grader: fuzzy")
+	})
+
+	t.Run("duplicate subtask id", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:        "print('')",
+			Language:    "C",
+			ProblemId:   1,
+			TimeLimit:   1000,
+			MemoryLimit: 100,
+			Subtasks:    []testcase.Subtask{{Id: 1, Score: 50}, {Id: 1, Score: 50}},
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "duplicate subtask id: 1")
+	})
+
+	t.Run("stopOnSubtaskFailure without subtasks", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:                 "print('')",
+			Language:             "C",
+			ProblemId:            1,
+			TimeLimit:            1000,
+			MemoryLimit:          100,
+			StopOnSubtaskFailure: true,
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "stopOnSubtaskFailure requires subtasks")
+	})
+
+	t.Run("negative floatTolerance", func(t *testing.T) {
+		t.Parallel()
+		req := JudgeRequest{
+			Code:           "print('')",
+			Language:       "C",
+			ProblemId:      1,
+			TimeLimit:      1000,
+			MemoryLimit:    100,
+			Grader:         "float",
+			FloatTolerance: -1,
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "floatTolerance must not be less than 0")
+	})
+}
+
+func TestValidateInteractive(t *testing.T) {
+	base := JudgeRequest{
+		Code:        "print('')",
+		Language:    "C",
+		ProblemId:   1,
+		TimeLimit:   1000,
+		MemoryLimit: 100,
+	}
+
+	t.Run("interactive without interactor", func(t *testing.T) {
+		t.Parallel()
+		req := base
+		req.IsInteractive = true
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "interactorCode must not be empty for interactive problems")
+	})
+
+	t.Run("interactive with checker", func(t *testing.T) {
+		t.Parallel()
+		req := base
+		req.IsInteractive = true
+		req.InteractorCode = "int main(){}"
+		req.InteractorLanguage = "Cpp"
+		req.CheckerCode = "int main(){}"
+		req.CheckerLanguage = "Cpp"
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "checkerCode must be empty for interactive problems")
+	})
+}
+
+func TestFactoryCreateInteractive(t *testing.T) {
+	factory := NewFactory(nil, nil, nil, nil, nil, nil)
+	base := `"code":"int main(){}","language":"C","problemId":1,"timeLimit":1000,"memoryLimit":100`
+
+	task, err := factory.Create("interactive", []byte(`{`+base+`,"interactorCode":"int main(){}","interactorLanguage":"Cpp"}`))
+	assert.Nil(t, err)
+
+	units := task.GetBuildUnits()
+	assert.Len(t, units, 2)
+	assert.Equal(t, InteractorUnitName, units[1].Name)
+	assert.NotNil(t, task.(*Task).interactor)
+	assert.True(t, task.(*Task).req.IsInteractive)
+}
+
+func TestFactoryCreateSpecialJudge(t *testing.T) {
+	factory := NewFactory(nil, nil, nil, nil, nil, nil)
+	base := `"code":"int main(){}","language":"C","problemId":1,"timeLimit":1000,"memoryLimit":100`
+
+	t.Run("requires a checker", func(t *testing.T) {
+		_, err := factory.Create("specialJudge", []byte(`{`+base+`}`))
+		assert.ErrorContains(t, err, "checkerCode must not be empty for special judge")
+	})
+
+	t.Run("builds the checker as a second unit", func(t *testing.T) {
+		task, err := factory.Create("specialJudge", []byte(`{`+base+`,"checkerCode":"int main(){}","checkerLanguage":"Cpp"}`))
+		assert.Nil(t, err)
+
+		units := task.GetBuildUnits()
+		assert.Len(t, units, 2)
+		assert.Equal(t, DefaultUnitName, units[0].Name)
+		assert.Equal(t, CheckerUnitName, units[1].Name)
+		assert.NotNil(t, task.(*Task).checker)
+	})
+}
+
+func TestFactoryCreateGrader(t *testing.T) {
+	factory := NewFactory(nil, nil, nil, nil, nil, nil)
+	base := `"code":"int main(){}","language":"C","problemId":1,"timeLimit":1000,"memoryLimit":100`
+
+	t.Run("defaults to exact", func(t *testing.T) {
+		task, err := factory.Create("judge", []byte(`{`+base+`}`))
+		assert.Nil(t, err)
+
+		g := task.(*Task).grader
+		assert.True(t, g.Grade([]byte("1 2\n"), []byte("1 2")))
+		assert.False(t, g.Grade([]byte("0.5"), []byte("0.5000001")))
+	})
+
+	t.Run("uses the requested grader", func(t *testing.T) {
+		task, err := factory.Create("judge", []byte(`{`+base+`,"grader":"float"}`))
+		assert.Nil(t, err)
+
+		assert.True(t, task.(*Task).grader.Grade([]byte("0.5"), []byte("0.5000001")))
+	})
 }
@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
 )
 
 type JudgeRequest struct {
@@ -13,13 +15,50 @@ type JudgeRequest struct {
 	ProblemId                int                  `json:"problemId"`
 	TimeLimit                int                  `json:"timeLimit"`
 	MemoryLimit              int                  `json:"memoryLimit"`
+	OutputLimit              int                  `json:"outputLimit,omitempty"`
+	RealTimeMultiplier       float64              `json:"realTimeMultiplier,omitempty"`
+	RealTimeGrace            int                  `json:"realTimeGrace,omitempty"`
+	TimeLimitScales          map[string]float64   `json:"timeLimitScales,omitempty"`
 	UserTestcases            *[]loader.ElementOut `json:"userTestcases,omitempty"`
 	StopOnNotAccepted        bool                 `json:"stopOnNotAccepted,omitempty"`
 	JudgeOnlyHiddenTestcases bool                 `json:"judgeOnlyHiddenTestcases,omitempty"`
 	ContainHiddenTestcases   bool                 `json:"containHiddenTestcases,omitempty"`
 	IsInteractive            bool                 `json:"isInteractive,omitempty"`
+	CheckerCode              string               `json:"checkerCode,omitempty"`
+	CheckerLanguage          string               `json:"checkerLanguage,omitempty"`
+	InteractorCode           string               `json:"interactorCode,omitempty"`
+	InteractorLanguage       string               `json:"interactorLanguage,omitempty"`
+	Grader                   string               `json:"grader,omitempty"`
+	FloatTolerance           float64              `json:"floatTolerance,omitempty"`
+	Subtasks                 []testcase.Subtask   `json:"subtasks,omitempty"`
+	StopOnSubtaskFailure     bool                 `json:"stopOnSubtaskFailure,omitempty"`
 }
 
+// TimeScaling overrides the time scaling of the language with the real time
+// budget of the request and the time limit scale given for the language.
+func (r JudgeRequest) TimeScaling() sandbox.TimeScaling {
+	return r.timeScalingOf(r.Language)
+}
+
+// InteractorTimeScaling is TimeScaling for the language of the interactor.
+func (r JudgeRequest) InteractorTimeScaling() sandbox.TimeScaling {
+	return r.timeScalingOf(r.InteractorLanguage)
+}
+
+func (r JudgeRequest) timeScalingOf(language string) sandbox.TimeScaling {
+	return sandbox.TimeScaling{
+		Scale:              sandbox.TimeLimitScale(r.TimeLimitScales, sandbox.Language(language)),
+		RealTimeMultiplier: r.RealTimeMultiplier,
+		RealTimeGrace:      r.RealTimeGrace,
+	}
+}
+
+const (
+	DefaultUnitName    = "default"
+	CheckerUnitName    = "checker"
+	InteractorUnitName = "interactor"
+)
+
 func (r JudgeRequest) Validate() (*JudgeRequest, error) {
 	if r.Code == "" {
 		return nil, fmt.Errorf("code must not be empty")
@@ -39,6 +78,54 @@ func (r JudgeRequest) Validate() (*JudgeRequest, error) {
 	if r.MemoryLimit <= 0 {
 		return nil, fmt.Errorf("memoryLimit must not be empty or less than 0")
 	}
+	if r.OutputLimit < 0 {
+		return nil, fmt.Errorf("outputLimit must not be less than 0")
+	}
+	for language, scale := range r.TimeLimitScales {
+		if scale <= 0 || scale > sandbox.MaxScale {
+			return nil, fmt.Errorf("timeLimitScales of %s must be greater than 0 and at most %d", language, sandbox.MaxScale)
+		}
+	}
+	if err := r.TimeScaling().Validate(); err != nil {
+		return nil, err
+	}
+	if r.Grader != "" && !grader.Strategy(r.Grader).IsValid() {
+		return nil, fmt.Errorf("unsupported grader: %s", r.Grader)
+	}
+	if r.FloatTolerance < 0 {
+		return nil, fmt.Errorf("floatTolerance must not be less than 0")
+	}
+	subtaskIds := make(map[int]bool, len(r.Subtasks))
+	for _, subtask := range r.Subtasks {
+		if subtaskIds[subtask.Id] {
+			return nil, fmt.Errorf("duplicate subtask id: %d", subtask.Id)
+		}
+		if subtask.Score < 0 {
+			return nil, fmt.Errorf("score of subtask %d must not be less than 0", subtask.Id)
+		}
+		subtaskIds[subtask.Id] = true
+	}
+	if r.StopOnSubtaskFailure && len(r.Subtasks) == 0 {
+		return nil, fmt.Errorf("stopOnSubtaskFailure requires subtasks")
+	}
+	if (r.CheckerCode == "") != (r.CheckerLanguage == "") {
+		return nil, fmt.Errorf("checkerCode and checkerLanguage must be provided together")
+	}
+	if r.CheckerLanguage != "" && !sandbox.Language(r.CheckerLanguage).IsValid() {
+		return nil, fmt.Errorf("unsupported checkerLanguage: %s", r.CheckerLanguage)
+	}
+	if (r.InteractorCode == "") != (r.InteractorLanguage == "") {
+		return nil, fmt.Errorf("interactorCode and interactorLanguage must be provided together")
+	}
+	if r.InteractorLanguage != "" && !sandbox.Language(r.InteractorLanguage).IsValid() {
+		return nil, fmt.Errorf("unsupported interactorLanguage: %s", r.InteractorLanguage)
+	}
+	if r.IsInteractive && r.InteractorCode == "" {
+		return nil, fmt.Errorf("interactorCode must not be empty for interactive problems")
+	}
+	if r.IsInteractive && r.CheckerCode != "" {
+		return nil, fmt.Errorf("checkerCode must be empty for interactive problems")
+	}
 	return &r, nil
 }
 
@@ -49,9 +136,28 @@ type JudgeResult struct {
 	RealTime   int    `json:"realTime"`
 	Memory     int    `json:"memory"`
 	Signal     int    `json:"signal"`
+	SignalName string `json:"signalName,omitempty"`
 	ExitCode   int    `json:"exitCode"`
 	ErrorCode  int    `json:"errorCode"`
 	Error      string `json:"error"`
+	// Stderr is cut to constants.MAX_STDERR bytes by the sandbox, and is set
+	// only for public testcases
+	Stderr string `json:"stderr"`
+	// Reason explains a runtime error in words, e.g. "division by zero"
+	Reason string `json:"reason,omitempty"`
+	// CheckerMessage is set only for public testcases
+	CheckerMessage string `json:"checkerMessage,omitempty"`
+	// Mismatch is set only for wrong answers on public testcases
+	Mismatch *grader.Mismatch `json:"mismatch,omitempty"`
+	// Interactor is set only for interactive problems
+	Interactor *InteractorResult `json:"interactor,omitempty"`
+}
+
+type InteractorResult struct {
+	CpuTime  int    `json:"cpuTime"`
+	RealTime int    `json:"realTime"`
+	Memory   int    `json:"memory"`
+	Message  string `json:"message"`
 }
 
 func (r *JudgeResult) SetJudgeExecResult(execResult sandbox.ExecResult) {
@@ -59,6 +165,17 @@ func (r *JudgeResult) SetJudgeExecResult(execResult sandbox.ExecResult) {
 	r.RealTime = execResult.RealTime
 	r.Memory = execResult.Memory
 	r.Signal = execResult.Signal
+	r.SignalName = sandbox.SignalName(execResult.Signal)
 	r.ExitCode = execResult.ExitCode
 	r.ErrorCode = execResult.ErrorCode
 }
+
+// SetStderr sets the stderr of the run and the reason of its runtime error.
+// The stderr of hidden testcases is left out as the submission may echo their
+// input there.
+func (r *JudgeResult) SetStderr(execResult sandbox.ExecResult, stderr []byte, hidden bool) {
+	if !hidden {
+		r.Stderr = string(stderr)
+	}
+	r.Reason = sandbox.RuntimeErrorReason(execResult, stderr)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 
 	instrumentation "github.com/skkuding/codedang/apps/iris/src"
 	"github.com/skkuding/codedang/apps/iris/src/common/constants"
@@ -13,6 +14,8 @@ import (
 	"github.com/skkuding/codedang/apps/iris/src/loader"
 	"github.com/skkuding/codedang/apps/iris/src/router/response"
 	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/checker"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
 	"github.com/skkuding/codedang/apps/iris/src/service/grader"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
@@ -28,8 +31,15 @@ type Task struct {
 	buildUnits []*build.BuildUnit
 	tcManager  testcase.TestcaseReader
 	sandbox    sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file       file.FileManager
+	cpuPool    *handler.CpuPool
 	logger     logger.Logger
 	tracer     trace.Tracer
+	grader     grader.Grader
+	// checker is set only for special judge requests
+	checker *checker.Checker
+	// interactor is set only for interactive problems
+	interactor *checker.Interactor
 }
 
 func (t *Task) GetDebugString() string {
@@ -53,8 +63,13 @@ func (t *Task) RunAction(ctx context.Context, messageID string, sendMessage hand
 		judgeResults = append(judgeResults, judgeResponse)
 		sendMessage(handler.ResultMessage{EncodedResponse: judgeResponse.Marshal()})
 	}
+	var subtaskScores []response.SubtaskScore
 	defer func() {
-		sendMessage(handler.ResultMessage{EncodedResponse: response.NewSubmissionResponse(messageID, judgeResults).Marshal()}, constants.Submission)
+		submission := response.NewSubmissionResponse(messageID, judgeResults)
+		if subtaskScores != nil {
+			submission.SetScore(subtaskScores)
+		}
+		sendMessage(handler.ResultMessage{EncodedResponse: submission.Marshal()}, constants.Submission)
 	}()
 
 	validReq := t.req
@@ -77,24 +92,192 @@ func (t *Task) RunAction(ctx context.Context, messageID string, sendMessage hand
 		tc = res
 	}
 
-	tcNum := len(tc.Elements)
-	for i, tElement := range tc.Elements {
-		if err := ctx.Err(); err != nil {
-			sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.CANCELED, logger.INFO, err)})
-			return
+	tc.Subtasks = validReq.Subtasks
+	subtaskIndices, err := tc.SubtaskIndices()
+	if err != nil {
+		sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.TESTCASE_ERROR, logger.ERROR, err)})
+		return
+	}
+
+	workerCount, err := handler.JudgeWorkerCountFromEnv(len(tc.Elements))
+	if err != nil {
+		sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, err)})
+		return
+	}
+	runDirs, err := t.makeRunDirs(workerCount)
+	if err != nil {
+		sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, err)})
+		return
+	}
+
+	codes := t.judgeTestcases(ctx, validReq, tc.Elements, runDirs, sendResult)
+	if len(tc.Subtasks) > 0 {
+		subtaskScores = scoreSubtasks(tc.Subtasks, subtaskIndices, codes)
+	}
+}
+
+// scoreSubtasks awards a subtask its full score only if all of its testcases
+// are accepted, that is, a subtask scores the minimum over its testcases.
+// A subtask without testcases scores nothing.
+func scoreSubtasks(subtasks []testcase.Subtask, indices map[int][]int, codes []handler.ResultCode) []response.SubtaskScore {
+	scores := make([]response.SubtaskScore, len(subtasks))
+	for i, subtask := range subtasks {
+		scores[i] = response.SubtaskScore{Id: subtask.Id, MaxScore: subtask.Score}
+		accepted := len(indices[subtask.Id]) > 0
+		for _, idx := range indices[subtask.Id] {
+			if codes[idx] != handler.ACCEPTED {
+				accepted = false
+				break
+			}
+		}
+		if accepted {
+			scores[i].Score = subtask.Score
+		}
+	}
+	return scores
+}
+
+// makeRunDirs gives every worker its own directory for run artifacts, so
+// that workers do not serialize on the solution unit. A single worker keeps
+// using the unit directory.
+func (t *Task) makeRunDirs(workerCount int) ([]string, error) {
+	if workerCount <= 1 {
+		return []string{""}, nil
+	}
+	runDirs := make([]string, workerCount)
+	for w := range runDirs {
+		runDirs[w] = fmt.Sprintf("%s/worker-%d", t.buildUnits[0].Dir, w)
+		if err := t.file.CreateDir(runDirs[w]); err != nil {
+			return nil, fmt.Errorf("creating run dir: %w", err)
+		}
+	}
+	return runDirs, nil
+}
+
+type testcaseOutcome struct {
+	code    handler.ResultCode
+	message *handler.ResultMessage
+}
+
+// failedSubtasks is shared by the workers so that they can skip testcases
+// of a subtask which already failed. It keeps the first failed testcase of
+// each subtask, since only testcases after it may be skipped.
+type failedSubtasks struct {
+	mu    sync.Mutex
+	first map[int]int
+}
+
+func (f *failedSubtasks) add(id int, idx int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if first, ok := f.first[id]; !ok || idx < first {
+		f.first[id] = idx
+	}
+}
+
+func (f *failedSubtasks) failedBefore(id int, idx int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	first, ok := f.first[id]
+	return ok && first < idx
+}
+
+// judgeTestcases runs one worker per run dir but reports results strictly in
+// testcase order, and returns the reported result code of every testcase.
+// With StopOnNotAccepted, results of testcases after the first failure are
+// discarded and reported as canceled, exactly as if the testcases had been
+// judged one by one. StopOnSubtaskFailure does the same within a subtask.
+func (t *Task) judgeTestcases(ctx context.Context, validReq *JudgeRequest, elements []loader.ElementOut,
+	runDirs []string, sendResult func(handler.ResultMessage)) []handler.ResultCode {
+	codes := make([]handler.ResultCode, len(elements))
+	for i := range codes {
+		codes[i] = handler.CANCELED
+	}
+	outcomes := make([]chan testcaseOutcome, len(elements))
+	for i := range outcomes {
+		outcomes[i] = make(chan testcaseOutcome, 1)
+	}
+	jobs := make(chan int)
+	stop := make(chan struct{})
+	failed := &failedSubtasks{first: map[int]int{}}
+	var wg sync.WaitGroup
+
+	worker := func(runDir string) {
+		defer wg.Done()
+		for i := range jobs {
+			subtask := elements[i].Subtask
+			if validReq.StopOnSubtaskFailure && failed.failedBefore(subtask, i) {
+				// an earlier testcase failed, so this one is reported as canceled
+				outcomes[i] <- testcaseOutcome{code: handler.CANCELED}
+				continue
+			}
+			var outcome testcaseOutcome
+			outcome.code = t.judgeTestcase(ctx, i, runDir, validReq, elements[i], func(message handler.ResultMessage) {
+				outcome.message = &message
+			})
+			if validReq.StopOnSubtaskFailure && outcome.code != handler.ACCEPTED {
+				failed.add(subtask, i)
+			}
+			outcomes[i] <- outcome
+		}
+	}
+
+	wg.Add(len(runDirs))
+	for _, runDir := range runDirs {
+		go worker(runDir)
+	}
+	go func() {
+		defer close(jobs)
+		for i := range elements {
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// Running testcases are not interrupted, but nothing may touch the run
+	// dirs once RunAction has returned.
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	reportedFailed := map[int]bool{}
+	for i := range elements {
+		if validReq.StopOnSubtaskFailure && reportedFailed[elements[i].Subtask] {
+			t.sendCancelResult(elements[i], sendResult)
+			continue
+		}
+
+		var outcome testcaseOutcome
+		select {
+		case outcome = <-outcomes[i]:
+		case <-ctx.Done():
 		}
-		judgeResultCode := t.judgeTestcase(ctx, i, validReq, tElement, sendResult)
+		if outcome.message == nil {
+			sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.CANCELED, logger.INFO, ctx.Err())})
+			return codes
+		}
+		sendResult(*outcome.message)
+		codes[i] = outcome.code
 		if ctx.Err() != nil {
-			return
+			return codes
 		}
 
-		if validReq.StopOnNotAccepted && judgeResultCode != handler.ACCEPTED {
-			for idxToCancel := i + 1; idxToCancel < tcNum; idxToCancel++ {
-				t.sendCancelResult(tc.Elements[idxToCancel], sendResult)
+		if outcome.code != handler.ACCEPTED {
+			reportedFailed[elements[i].Subtask] = true
+		}
+		if validReq.StopOnNotAccepted && outcome.code != handler.ACCEPTED {
+			for _, element := range elements[i+1:] {
+				t.sendCancelResult(element, sendResult)
 			}
-			break
+			return codes
 		}
 	}
+	return codes
 }
 
 func (t *Task) SendSetupFailure(messageID string, taskErr error, sendMessage handler.ResultSender) {
@@ -105,7 +288,7 @@ func (t *Task) SendSetupFailure(messageID string, taskErr error, sendMessage han
 	}, constants.Submission)
 }
 
-func (t *Task) judgeTestcase(ctx context.Context, idx int, validReq *JudgeRequest,
+func (t *Task) judgeTestcase(ctx context.Context, idx int, runDir string, validReq *JudgeRequest,
 	tc loader.ElementOut, sendResult func(handler.ResultMessage)) handler.ResultCode {
 	ctx, childSpan := t.tracer.Start(
 		ctx,
@@ -118,12 +301,27 @@ func (t *Task) judgeTestcase(ctx context.Context, idx int, validReq *JudgeReques
 		return handler.CANCELED
 	}
 
+	cpuSet, err := t.cpuPool.Acquire(ctx)
+	if err != nil {
+		return handler.CANCELED
+	}
+	defer t.cpuPool.Release(cpuSet)
+
 	res := JudgeResult{TestcaseId: tc.Id}
 
-	runResult, err := t.buildUnits[0].Run(t.sandbox, sandbox.RunRequest{
-		Order:       idx,
-		TimeLimit:   validReq.TimeLimit,
-		MemoryLimit: validReq.MemoryLimit,
+	if t.interactor != nil {
+		return t.sendJudgeResult(res, t.interactTestcase(idx, cpuSet, validReq, tc, &res), sendResult)
+	}
+
+	runResult, err := t.buildUnits[0].RunIn(t.sandbox, runDir, sandbox.RunRequest{
+		Order:        idx,
+		TimeLimit:    validReq.TimeLimit,
+		MemoryLimit:  validReq.MemoryLimit,
+		OutputLimit:  validReq.OutputLimit,
+		TimeScaling:  validReq.TimeScaling(),
+		CpuSet:       cpuSet,
+		InputPath:    tc.InPath,
+		OutputOnDisk: tc.OnDisk(),
 	}, []byte(tc.In))
 
 	var accepted bool
@@ -143,23 +341,41 @@ func (t *Task) judgeTestcase(ctx context.Context, idx int, validReq *JudgeReques
 	}
 
 	res.SetJudgeExecResult(runResult.ExecResult)
-	res.Output = string(runResult.Output)
-
-	if len(res.Output) > constants.MAX_OUTPUT {
-		res.Output = res.Output[:constants.MAX_OUTPUT]
+	res.SetStderr(runResult.ExecResult, runResult.ErrOutput, tc.Hidden)
+	res.Output, err = handler.OutputPreview(runResult)
+	if err != nil {
+		t.logger.Log(logger.WARN, fmt.Sprintf("failed to read output of testcase %d: %s", tc.Id, err.Error()))
 	}
 
 	if runResult.ExecResult.StatusCode != sandbox.RUN_SUCCESS {
 		goto Send
 	}
 
-	accepted = grader.Grade([]byte(tc.Out), runResult.Output)
+	if t.checker != nil {
+		judgeResultCode = t.checkOutput(idx, tc, runResult, &res)
+		goto Send
+	}
+
+	accepted, err = handler.GradeOutput(t.grader, tc, runResult)
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Error while grading testcase %d: %s", tc.Id, err.Error()))
+		judgeResultCode = handler.SERVER_ERROR
+		goto Send
+	}
 
 	if !accepted {
 		judgeResultCode = handler.WRONG_ANSWER
+		// 히든 테스트케이스의 정답은 diff로도 노출하지 않고, 디스크의 큰 테스트케이스는 diff를 계산하지 않음
+		if !tc.Hidden && !tc.OnDisk() {
+			res.Mismatch = t.grader.Diff([]byte(tc.Out), runResult.Output)
+		}
 	}
 
 Send:
+	return t.sendJudgeResult(res, judgeResultCode, sendResult)
+}
+
+func (t *Task) sendJudgeResult(res JudgeResult, judgeResultCode handler.ResultCode, sendResult func(handler.ResultMessage)) handler.ResultCode {
 	marshaledRes, err := json.Marshal(res)
 	if err != nil {
 		sendResult(handler.ResultMessage{Result: nil, Err: handler.NewTaskError("judge", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("marshal failed"))})
@@ -169,6 +385,129 @@ Send:
 	return judgeResultCode
 }
 
+// interactTestcase runs the submission against the interactor. The interactor
+// gets the tool limits on top of the problem time limit so that it outlives a
+// solution which is idle-waiting for input.
+func (t *Task) interactTestcase(idx int, cpuSet []int, validReq *JudgeRequest, tc loader.ElementOut, res *JudgeResult) handler.ResultCode {
+	limits, err := handler.ToolLimitsFromEnv()
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Invalid interactor limits: %s", err.Error()))
+		return handler.SERVER_ERROR
+	}
+
+	// the interactor reads the testcase from its own copy of the files
+	input, err := tc.ReadIn()
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Failed to read testcase %d: %s", tc.Id, err.Error()))
+		return handler.SERVER_ERROR
+	}
+	answer, err := tc.ReadOut()
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Failed to read testcase %d: %s", tc.Id, err.Error()))
+		return handler.SERVER_ERROR
+	}
+
+	// the interactor waits for the solution, so it gets the scaled time limit
+	// of the solution on top of its own
+	solutionTimeLimit, _ := validReq.TimeScaling().Limits(validReq.TimeLimit)
+	interactResult, err := t.interactor.Interact(t.buildUnits[0], sandbox.RunRequest{
+		Order:       idx,
+		TimeLimit:   validReq.TimeLimit,
+		MemoryLimit: validReq.MemoryLimit,
+		OutputLimit: validReq.OutputLimit,
+		CpuSet:      cpuSet,
+		TimeScaling: validReq.TimeScaling(),
+	}, sandbox.RunRequest{
+		Order:       idx,
+		TimeLimit:   solutionTimeLimit + limits.TimeLimit,
+		MemoryLimit: limits.MemoryLimit,
+		TimeScaling: validReq.InteractorTimeScaling(),
+	}, input, answer)
+
+	// Cgroup 경로 삭제
+	for _, cgroupPath := range []string{interactResult.Solution.CgroupPath, interactResult.Interactor.CgroupPath} {
+		if cgroupPath == "" {
+			continue
+		}
+		if err := os.RemoveAll(cgroupPath); err != nil {
+			t.logger.Log(logger.WARN, fmt.Sprintf("failed to clean up run cgroup dir %s: %v", cgroupPath, err))
+		}
+	}
+
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Error while running interactor: %s", err.Error()))
+		return handler.SERVER_ERROR
+	}
+
+	res.SetJudgeExecResult(interactResult.Solution)
+	res.SetStderr(interactResult.Solution, interactResult.SolutionErrOutput, tc.Hidden)
+	res.Interactor = &InteractorResult{
+		CpuTime:  interactResult.Interactor.CpuTime,
+		RealTime: interactResult.Interactor.RealTime,
+		Memory:   interactResult.Interactor.Memory,
+		Message:  interactResult.Message,
+	}
+	if interactResult.Verdict == checker.FAIL {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Interactor failed on testcase %d: %s", tc.Id, interactResult.Message))
+	}
+	return interactiveResultCode(interactResult.Solution, interactResult.Verdict)
+}
+
+// interactiveResultCode prefers the solution's limit verdicts, then the
+// interactor's verdict, since a solution usually crashes (e.g. SIGPIPE) only
+// after the interactor already gave up on it.
+func interactiveResultCode(solution sandbox.ExecResult, verdict checker.Verdict) handler.ResultCode {
+	switch solution.StatusCode {
+	case sandbox.CPU_TIME_LIMIT_EXCEEDED, sandbox.REAL_TIME_LIMIT_EXCEEDED, sandbox.MEMORY_LIMIT_EXCEEDED:
+		return handler.SandboxStatusCodeToJudgeResultCode(solution.StatusCode)
+	}
+	switch verdict {
+	case checker.WRONG_ANSWER, checker.PRESENTATION_ERROR:
+		return handler.WRONG_ANSWER
+	case checker.FAIL:
+		return handler.SERVER_ERROR
+	}
+	return handler.SandboxStatusCodeToJudgeResultCode(solution.StatusCode)
+}
+
+// checkOutput grades a special judge output with the compiled checker.
+func (t *Task) checkOutput(idx int, tc loader.ElementOut, runResult sandbox.RunResult, res *JudgeResult) handler.ResultCode {
+	limits, err := handler.ToolLimitsFromEnv()
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Invalid checker limits: %s", err.Error()))
+		return handler.SERVER_ERROR
+	}
+
+	req := sandbox.RunRequest{
+		Order:       idx,
+		TimeLimit:   limits.TimeLimit,
+		MemoryLimit: limits.MemoryLimit,
+	}
+	var checkResult checker.Result
+	if tc.OnDisk() {
+		checkResult, err = t.checker.CheckTestcase(req, tc, runResult.OutputPath)
+	} else {
+		checkResult, err = t.checker.Check(req, []byte(tc.In), runResult.Output, []byte(tc.Out))
+	}
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Error while running checker: %s", err.Error()))
+		return handler.SERVER_ERROR
+	}
+	// testlib checkers usually print the expected answer in their message
+	if !tc.Hidden {
+		res.CheckerMessage = checkResult.Message
+	}
+
+	switch checkResult.Verdict {
+	case checker.OK:
+		return handler.ACCEPTED
+	case checker.WRONG_ANSWER, checker.PRESENTATION_ERROR:
+		return handler.WRONG_ANSWER
+	}
+	t.logger.Log(logger.ERROR, fmt.Sprintf("Checker failed on testcase %d: %s", tc.Id, checkResult.Message))
+	return handler.SERVER_ERROR
+}
+
 func (t *Task) sendCancelResult(element loader.ElementOut, sendResult func(handler.ResultMessage)) {
 	canceledResult := JudgeResult{
 		TestcaseId: element.Id,
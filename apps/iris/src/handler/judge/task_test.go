@@ -0,0 +1,232 @@
+package judge
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/checker"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+// echoSandbox echoes the input back as output. Later testcases finish first,
+// so that results complete out of order when run concurrently.
+type echoSandbox struct {
+	total int
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	outputDirs map[string]bool
+}
+
+func (s *echoSandbox) Run(req sandbox.RunRequest, input []byte) (sandbox.RunResult, error) {
+	s.mu.Lock()
+	s.running++
+	s.maxRunning = max(s.maxRunning, s.running)
+	s.outputDirs[req.OutputDir] = true
+	s.mu.Unlock()
+
+	time.Sleep(time.Duration(s.total-req.Order) * 5 * time.Millisecond)
+
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	return sandbox.RunResult{Order: req.Order, Output: input}, nil
+}
+
+func (*echoSandbox) RunInteractive(sandbox.RunRequest, sandbox.RunRequest) (sandbox.InteractiveRunResult, error) {
+	return sandbox.InteractiveRunResult{}, nil
+}
+
+func (*echoSandbox) Compile(sandbox.CompileRequest) (sandbox.CompileResult, error) {
+	return sandbox.CompileResult{}, nil
+}
+
+func (*echoSandbox) GetConfig(sandbox.Language) (judger.JudgerConfig, error) {
+	return judger.JudgerConfig{}, nil
+}
+
+func (*echoSandbox) MakeSrcPath(string, sandbox.Language) (string, error) {
+	return "", nil
+}
+
+func (*echoSandbox) ToCompileExecArgs(string, sandbox.Language) (judger.ExecArgs, error) {
+	return judger.ExecArgs{}, nil
+}
+
+func (*echoSandbox) ToRunExecArgs(string, sandbox.Language, int, sandbox.Limit, bool, []string) (judger.ExecArgs, error) {
+	return judger.ExecArgs{}, nil
+}
+
+func TestInteractiveResultCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		solution sandbox.ExecResult
+		verdict  checker.Verdict
+		want     handler.ResultCode
+	}{
+		{"accepted", sandbox.ExecResult{StatusCode: sandbox.RUN_SUCCESS}, checker.OK, handler.ACCEPTED},
+		{"wrong answer", sandbox.ExecResult{StatusCode: sandbox.RUN_SUCCESS}, checker.WRONG_ANSWER, handler.WRONG_ANSWER},
+		{"wrong answer wins over broken pipe", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, Signal: 13}, checker.WRONG_ANSWER, handler.WRONG_ANSWER},
+		{"time limit wins over interactor verdict", sandbox.ExecResult{StatusCode: sandbox.CPU_TIME_LIMIT_EXCEEDED}, checker.WRONG_ANSWER, handler.CPU_TIME_LIMIT_EXCEEDED},
+		{"runtime error with ok interactor", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR}, checker.OK, handler.RUNTIME_ERROR},
+		{"interactor failure", sandbox.ExecResult{StatusCode: sandbox.RUN_SUCCESS}, checker.FAIL, handler.SERVER_ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, interactiveResultCode(tt.solution, tt.verdict))
+		})
+	}
+}
+
+func TestJudgeTestcasesConcurrently(t *testing.T) {
+	// testcase 4 is a wrong answer in subtask 1
+	elements := make([]loader.ElementOut, 6)
+	for i := range elements {
+		elements[i] = loader.ElementOut{Id: i + 1, In: "x", Out: "x", Subtask: i % 2}
+	}
+	elements[3].Out = "y"
+
+	newTask := func(t *testing.T, fake *echoSandbox, req JudgeRequest, workers int) (*Task, []string) {
+		baseDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(baseDir, "unit"), 0o755))
+		exact, err := grader.New(grader.EXACT, 0)
+		require.NoError(t, err)
+
+		task := &Task{
+			req:        &req,
+			buildUnits: []*build.BuildUnit{{Name: DefaultUnitName, Dir: "unit"}},
+			sandbox:    fake,
+			file:       file.NewFileManager(baseDir),
+			logger:     noopLogger{},
+			tracer:     noop.NewTracerProvider().Tracer(""),
+			grader:     exact,
+		}
+		runDirs, err := task.makeRunDirs(workers)
+		require.NoError(t, err)
+		return task, runDirs
+	}
+
+	judge := func(t *testing.T, task *Task, elements []loader.ElementOut, runDirs []string) ([]int, []handler.ResultCode) {
+		var ids []int
+		var codes []handler.ResultCode
+		returned := task.judgeTestcases(context.Background(), task.req, elements, runDirs, func(message handler.ResultMessage) {
+			var res JudgeResult
+			require.NoError(t, json.Unmarshal(message.Result, &res))
+			ids = append(ids, res.TestcaseId)
+			code := handler.ACCEPTED
+			if message.Err != nil {
+				code = handler.ExtractResultCode(message.Err)
+			}
+			codes = append(codes, code)
+		})
+		assert.Equal(t, codes, returned)
+		return ids, codes
+	}
+
+	t.Run("reports in testcase order", func(t *testing.T) {
+		fake := &echoSandbox{total: len(elements), outputDirs: map[string]bool{}}
+		task, runDirs := newTask(t, fake, JudgeRequest{TimeLimit: 1000, MemoryLimit: 1024}, 3)
+
+		ids, codes := judge(t, task, elements, runDirs)
+
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, ids)
+		assert.Equal(t, handler.WRONG_ANSWER, codes[3])
+		assert.Equal(t, handler.ACCEPTED, codes[5])
+		assert.Equal(t, 3, fake.maxRunning)
+		assert.Equal(t, map[string]bool{"unit/worker-0": true, "unit/worker-1": true, "unit/worker-2": true}, fake.outputDirs)
+	})
+
+	t.Run("cancels testcases after the first failure", func(t *testing.T) {
+		fake := &echoSandbox{total: len(elements), outputDirs: map[string]bool{}}
+		task, runDirs := newTask(t, fake, JudgeRequest{TimeLimit: 1000, MemoryLimit: 1024, StopOnNotAccepted: true}, 3)
+
+		ids, codes := judge(t, task, elements, runDirs)
+
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, ids)
+		assert.Equal(t, []handler.ResultCode{
+			handler.ACCEPTED, handler.ACCEPTED, handler.ACCEPTED,
+			handler.WRONG_ANSWER, handler.CANCELED, handler.CANCELED,
+		}, codes)
+	})
+
+	t.Run("cancels the rest of a failed subtask", func(t *testing.T) {
+		fake := &echoSandbox{total: len(elements), outputDirs: map[string]bool{}}
+		task, runDirs := newTask(t, fake, JudgeRequest{TimeLimit: 1000, MemoryLimit: 1024, StopOnSubtaskFailure: true}, 3)
+
+		ids, codes := judge(t, task, elements, runDirs)
+
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, ids)
+		assert.Equal(t, []handler.ResultCode{
+			handler.ACCEPTED, handler.ACCEPTED, handler.ACCEPTED,
+			handler.WRONG_ANSWER, handler.ACCEPTED, handler.CANCELED,
+		}, codes)
+	})
+
+	t.Run("does not skip a slow testcase before the failure of its subtask", func(t *testing.T) {
+		// testcase 1 is the slowest and testcase 2 fails while it still runs
+		elements := []loader.ElementOut{
+			{Id: 1, In: "x", Out: "x", Subtask: 1},
+			{Id: 2, In: "x", Out: "y", Subtask: 1},
+			{Id: 3, In: "x", Out: "x", Subtask: 1},
+		}
+		fake := &echoSandbox{total: len(elements), outputDirs: map[string]bool{}}
+		task, runDirs := newTask(t, fake, JudgeRequest{TimeLimit: 1000, MemoryLimit: 1024, StopOnSubtaskFailure: true}, 2)
+
+		ids, codes := judge(t, task, elements, runDirs)
+
+		assert.Equal(t, []int{1, 2, 3}, ids)
+		assert.Equal(t, []handler.ResultCode{handler.ACCEPTED, handler.WRONG_ANSWER, handler.CANCELED}, codes)
+	})
+}
+
+func TestFailedSubtasks(t *testing.T) {
+	failed := &failedSubtasks{first: map[int]int{}}
+
+	failed.add(1, 3)
+	assert.False(t, failed.failedBefore(1, 2), "a later failure must not skip an earlier testcase")
+	assert.True(t, failed.failedBefore(1, 4))
+	assert.False(t, failed.failedBefore(2, 4))
+
+	failed.add(1, 1)
+	assert.True(t, failed.failedBefore(1, 2))
+}
+
+func TestScoreSubtasks(t *testing.T) {
+	subtasks := []testcase.Subtask{{Id: 0, Score: 0}, {Id: 1, Score: 30}, {Id: 2, Score: 70}, {Id: 3, Score: 10}}
+	indices := map[int][]int{0: {0}, 1: {1, 3}, 2: {2, 4}, 3: {}}
+	codes := []handler.ResultCode{handler.ACCEPTED, handler.ACCEPTED, handler.ACCEPTED, handler.WRONG_ANSWER, handler.ACCEPTED}
+
+	scores := scoreSubtasks(subtasks, indices, codes)
+
+	assert.Equal(t, []response.SubtaskScore{
+		{Id: 0, Score: 0, MaxScore: 0},
+		{Id: 1, Score: 0, MaxScore: 30},
+		{Id: 2, Score: 70, MaxScore: 70},
+		{Id: 3, Score: 0, MaxScore: 10},
+	}, scores)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// LanesEnv lists the lanes messages are consumed from as
+	// "name:queue:weight" entries, e.g.
+	// "contest:client.q.judge.contest:8,practice:client.q.judge.submission:1".
+	LanesEnv    = "JUDGE_LANES"
+	DefaultLane = "default"
+)
+
+// Lane is a stream of messages with its own share of the task slots. When
+// tasks of several lanes wait for a slot, a lane with weight 8 is served
+// eight times as often as a lane with weight 1.
+type Lane struct {
+	Name   string
+	Queue  string
+	Weight int
+}
+
+// LanesFromEnv returns the configured lanes, or a single default lane
+// consuming from defaultQueue.
+func LanesFromEnv(defaultQueue string) ([]Lane, error) {
+	raw := os.Getenv(LanesEnv)
+	if raw == "" {
+		return []Lane{{Name: DefaultLane, Queue: defaultQueue, Weight: 1}}, nil
+	}
+	lanes, err := parseLanes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a list like \"contest:queue:8,practice:queue:1\": %w", LanesEnv, err)
+	}
+	return lanes, nil
+}
+
+func parseLanes(raw string) ([]Lane, error) {
+	var lanes []Lane
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid lane %q", entry)
+		}
+		weight, err := strconv.Atoi(parts[2])
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("invalid weight of lane %q", parts[0])
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("duplicate lane %q", parts[0])
+		}
+		seen[parts[0]] = true
+		lanes = append(lanes, Lane{Name: parts[0], Queue: parts[1], Weight: weight})
+	}
+	return lanes, nil
+}
+
+// PrefetchCount returns how many unacknowledged messages each lane should be
+// handed by the broker. With a single message per lane, lanes never wait for
+// a judge slot while there are more slots than lanes, and their weights have
+// no effect. With several lanes it is therefore one more than the judge
+// slots, so that a busy lane has a task waiting even while it runs on every
+// slot, and freed slots go to the lanes by weight.
+func PrefetchCount(lanes []Lane, admission *Admission) int {
+	limit := admission.Limit(JudgeClass)
+	if len(lanes) < 2 || limit == 0 {
+		return 1
+	}
+	return limit + 1
+}
+
+type laneKey struct{}
+
+// WithLane tags the tasks started with ctx as belonging to lane.
+func WithLane(ctx context.Context, lane string) context.Context {
+	return context.WithValue(ctx, laneKey{}, lane)
+}
+
+func LaneFrom(ctx context.Context) string {
+	if lane, ok := ctx.Value(laneKey{}).(string); ok && lane != "" {
+		return lane
+	}
+	return DefaultLane
+}
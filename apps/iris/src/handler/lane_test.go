@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanesFromEnv(t *testing.T) {
+	t.Run("defaults to a single lane", func(t *testing.T) {
+		t.Setenv(LanesEnv, "")
+		lanes, err := LanesFromEnv("client.q.judge.submission")
+		require.NoError(t, err)
+		assert.Equal(t, []Lane{{Name: DefaultLane, Queue: "client.q.judge.submission", Weight: 1}}, lanes)
+	})
+
+	t.Run("parses lanes", func(t *testing.T) {
+		t.Setenv(LanesEnv, "contest:client.q.judge.contest:8, practice:client.q.judge.submission:1")
+		lanes, err := LanesFromEnv("")
+		require.NoError(t, err)
+		assert.Equal(t, []Lane{
+			{Name: "contest", Queue: "client.q.judge.contest", Weight: 8},
+			{Name: "practice", Queue: "client.q.judge.submission", Weight: 1},
+		}, lanes)
+	})
+
+	t.Run("rejects invalid lanes", func(t *testing.T) {
+		for _, raw := range []string{"contest", "contest:q:0", "contest:q:x", ":q:1", "a:q:1,a:r:1"} {
+			_, err := parseLanes(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+func TestPrefetchCount(t *testing.T) {
+	lanes := []Lane{{Name: "contest", Weight: 8}, {Name: "practice", Weight: 1}}
+	admission := NewAdmission(map[TaskClass]int{JudgeClass: 4}, nil, nil, 0, 0)
+
+	assert.Equal(t, 5, PrefetchCount(lanes, admission))
+	assert.Equal(t, 1, PrefetchCount(lanes[:1], admission))
+	assert.Equal(t, 1, PrefetchCount(lanes, nil))
+}
+
+func TestLaneFrom(t *testing.T) {
+	assert.Equal(t, DefaultLane, LaneFrom(context.Background()))
+	assert.Equal(t, "contest", LaneFrom(WithLane(context.Background(), "contest")))
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+)
+
+// GradeOutput grades the output of a run against the answer of tc. Testcases
+// on disk are run with sandbox.RunRequest.OutputOnDisk and compared by
+// streaming both files, so that neither is loaded into memory.
+func GradeOutput(g grader.Grader, tc loader.ElementOut, result sandbox.RunResult) (bool, error) {
+	if !tc.OnDisk() {
+		return g.Grade([]byte(tc.Out), result.Output), nil
+	}
+
+	answer, err := tc.OpenOut()
+	if err != nil {
+		return false, fmt.Errorf("opening answer: %w", err)
+	}
+	defer answer.Close()
+	output, err := os.Open(result.OutputPath)
+	if err != nil {
+		return false, fmt.Errorf("opening output: %w", err)
+	}
+	defer output.Close()
+
+	return grader.GradeReader(g, answer, output)
+}
+
+// OutputPreview returns the output of a run cut to constants.MAX_OUTPUT bytes,
+// reading only that much of the output file if the output was left on disk.
+func OutputPreview(result sandbox.RunResult) (string, error) {
+	if result.Output != nil || result.OutputPath == "" {
+		return string(result.Output[:min(len(result.Output), constants.MAX_OUTPUT)]), nil
+	}
+
+	output, err := os.Open(result.OutputPath)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+	preview, err := io.ReadAll(io.LimitReader(output, constants.MAX_OUTPUT))
+	return string(preview), err
+}
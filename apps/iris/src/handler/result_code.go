@@ -19,6 +19,7 @@ const (
 	SEGMENTATION_FAULT_ERROR = taskerror.SEGMENTATION_FAULT_ERROR
 	SERVER_ERROR             = taskerror.SERVER_ERROR
 	CANCELED                 = taskerror.CANCELED
+	OUTPUT_LIMIT_EXCEEDED    = taskerror.OUTPUT_LIMIT_EXCEEDED
 )
 
 func SandboxStatusCodeToJudgeResultCode(status sandbox.StatusCode) ResultCode {
@@ -39,6 +40,8 @@ func SandboxStatusCodeToJudgeResultCode(status sandbox.StatusCode) ResultCode {
 		return SEGMENTATION_FAULT_ERROR
 	case sandbox.SERVER_ERROR:
 		return SERVER_ERROR
+	case sandbox.OUTPUT_LIMIT_EXCEEDED:
+		return OUTPUT_LIMIT_EXCEEDED
 	}
 	return SERVER_ERROR
 }
@@ -11,5 +11,6 @@ func TestSandboxStatusCodeToJudgeResultCode(t *testing.T) {
 	assert.Equal(t, ACCEPTED, SandboxStatusCodeToJudgeResultCode(sandbox.RUN_SUCCESS))
 	assert.Equal(t, COMPILE_ERROR, SandboxStatusCodeToJudgeResultCode(sandbox.COMPILE_ERROR))
 	assert.Equal(t, SEGMENTATION_FAULT_ERROR, SandboxStatusCodeToJudgeResultCode(sandbox.SEGMENTATION_FAULT_ERROR))
+	assert.Equal(t, OUTPUT_LIMIT_EXCEEDED, SandboxStatusCodeToJudgeResultCode(sandbox.OUTPUT_LIMIT_EXCEEDED))
 	assert.Equal(t, SERVER_ERROR, SandboxStatusCodeToJudgeResultCode(sandbox.StatusCode(127)))
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/skkuding/codedang/apps/iris/src/handler"
 	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
@@ -55,6 +56,11 @@ func (f *Factory) Create(taskType string, data []byte) (handler.Task, error) {
 		return nil, handler.NewTaskError("run", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("unknown taskType: %s", taskType))
 	}
 
+	outputGrader, err := grader.New(grader.Strategy(validReq.Grader), validReq.FloatTolerance)
+	if err != nil {
+		return nil, handler.NewTaskError("run", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("validation failed: %w", err))
+	}
+
 	buildUnits := []*build.BuildUnit{
 		{
 			Name:     "default",
@@ -71,6 +77,7 @@ func (f *Factory) Create(taskType string, data []byte) (handler.Task, error) {
 		sandbox:    f.sandbox,
 		logger:     f.logger,
 		tracer:     f.tracer,
+		grader:     outputGrader,
 	}
 
 	return task, nil
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/service/grader"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
 )
 
@@ -13,11 +14,17 @@ type RunRequest struct {
 	ProblemId                int                  `json:"problemId"`
 	TimeLimit                int                  `json:"timeLimit"`
 	MemoryLimit              int                  `json:"memoryLimit"`
+	OutputLimit              int                  `json:"outputLimit,omitempty"`
+	RealTimeMultiplier       float64              `json:"realTimeMultiplier,omitempty"`
+	RealTimeGrace            int                  `json:"realTimeGrace,omitempty"`
+	TimeLimitScales          map[string]float64   `json:"timeLimitScales,omitempty"`
 	UserTestcases            *[]loader.ElementOut `json:"userTestcases,omitempty"`
 	StopOnNotAccepted        bool                 `json:"stopOnNotAccepted,omitempty"`
 	JudgeOnlyHiddenTestcases bool                 `json:"judgeOnlyHiddenTestcases,omitempty"`
 	ContainHiddenTestcases   bool                 `json:"containHiddenTestcases,omitempty"`
 	IsInteractive            bool                 `json:"isInteractive,omitempty"`
+	Grader                   string               `json:"grader,omitempty"`
+	FloatTolerance           float64              `json:"floatTolerance,omitempty"`
 }
 
 func (r RunRequest) Validate() (*RunRequest, error) {
@@ -39,9 +46,36 @@ func (r RunRequest) Validate() (*RunRequest, error) {
 	if r.MemoryLimit <= 0 {
 		return nil, fmt.Errorf("memoryLimit must not be empty or less than 0")
 	}
+	if r.OutputLimit < 0 {
+		return nil, fmt.Errorf("outputLimit must not be less than 0")
+	}
+	for language, scale := range r.TimeLimitScales {
+		if scale <= 0 || scale > sandbox.MaxScale {
+			return nil, fmt.Errorf("timeLimitScales of %s must be greater than 0 and at most %d", language, sandbox.MaxScale)
+		}
+	}
+	if err := r.TimeScaling().Validate(); err != nil {
+		return nil, err
+	}
+	if r.Grader != "" && !grader.Strategy(r.Grader).IsValid() {
+		return nil, fmt.Errorf("unsupported grader: %s", r.Grader)
+	}
+	if r.FloatTolerance < 0 {
+		return nil, fmt.Errorf("floatTolerance must not be less than 0")
+	}
 	return &r, nil
 }
 
+// TimeScaling overrides the time scaling of the language with the real time
+// budget of the request and the time limit scale given for the language.
+func (r RunRequest) TimeScaling() sandbox.TimeScaling {
+	return sandbox.TimeScaling{
+		Scale:              sandbox.TimeLimitScale(r.TimeLimitScales, sandbox.Language(r.Language)),
+		RealTimeMultiplier: r.RealTimeMultiplier,
+		RealTimeGrace:      r.RealTimeGrace,
+	}
+}
+
 type RunResult struct {
 	TestcaseId int    `json:"testcaseId"`
 	Output     string `json:"output"`
@@ -49,9 +83,16 @@ type RunResult struct {
 	RealTime   int    `json:"realTime"`
 	Memory     int    `json:"memory"`
 	Signal     int    `json:"signal"`
+	SignalName string `json:"signalName,omitempty"`
 	ExitCode   int    `json:"exitCode"`
 	ErrorCode  int    `json:"errorCode"`
 	Error      string `json:"error"`
+	// Stderr is cut to constants.MAX_STDERR bytes by the sandbox
+	Stderr string `json:"stderr"`
+	// Reason explains a runtime error in words, e.g. "division by zero"
+	Reason string `json:"reason,omitempty"`
+	// Mismatch is set only for wrong answers on public testcases
+	Mismatch *grader.Mismatch `json:"mismatch,omitempty"`
 }
 
 func (r *RunResult) SetRunExecResult(execResult sandbox.ExecResult) {
@@ -59,6 +100,13 @@ func (r *RunResult) SetRunExecResult(execResult sandbox.ExecResult) {
 	r.RealTime = execResult.RealTime
 	r.Memory = execResult.Memory
 	r.Signal = execResult.Signal
+	r.SignalName = sandbox.SignalName(execResult.Signal)
 	r.ExitCode = execResult.ExitCode
 	r.ErrorCode = execResult.ErrorCode
 }
+
+// SetStderr sets the stderr of the run and the reason of its runtime error.
+func (r *RunResult) SetStderr(execResult sandbox.ExecResult, stderr []byte) {
+	r.Stderr = string(stderr)
+	r.Reason = sandbox.RuntimeErrorReason(execResult, stderr)
+}
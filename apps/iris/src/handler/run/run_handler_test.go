@@ -96,4 +96,20 @@ func TestValidate(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.Nil(t, err)
 	})
+
+	t.Run("unsupported grader", func(t *testing.T) {
+		t.Parallel()
+		req := RunRequest{
+			Code:        "print('')",
+			Language:    "C",
+			ProblemId:   1,
+			TimeLimit:   1000,
+			MemoryLimit: 100,
+			Grader:      "fuzzy",
+		}
+		result, err := req.Validate()
+
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "unsupported grader: fuzzy")
+	})
 }
@@ -30,6 +30,7 @@ type Task struct {
 	sandbox    sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
 	logger     logger.Logger
 	tracer     trace.Tracer
+	grader     grader.Grader
 }
 
 func (t *Task) GetDebugString() string {
@@ -134,9 +135,13 @@ func (t *Task) runTestcase(ctx context.Context, idx int, validReq *RunRequest,
 	res := RunResult{TestcaseId: tc.Id}
 
 	runResult, err := t.buildUnits[0].Run(t.sandbox, sandbox.RunRequest{
-		Order:       idx,
-		TimeLimit:   validReq.TimeLimit,
-		MemoryLimit: validReq.MemoryLimit,
+		Order:        idx,
+		TimeLimit:    validReq.TimeLimit,
+		MemoryLimit:  validReq.MemoryLimit,
+		OutputLimit:  validReq.OutputLimit,
+		TimeScaling:  validReq.TimeScaling(),
+		InputPath:    tc.InPath,
+		OutputOnDisk: tc.OnDisk(),
 	}, []byte(tc.In))
 
 	var accepted bool
@@ -156,20 +161,29 @@ func (t *Task) runTestcase(ctx context.Context, idx int, validReq *RunRequest,
 	}
 
 	res.SetRunExecResult(runResult.ExecResult)
-	res.Output = string(runResult.Output)
-
-	if len(res.Output) > constants.MAX_OUTPUT {
-		res.Output = res.Output[:constants.MAX_OUTPUT]
+	res.SetStderr(runResult.ExecResult, runResult.ErrOutput)
+	res.Output, err = handler.OutputPreview(runResult)
+	if err != nil {
+		t.logger.Log(logger.WARN, fmt.Sprintf("failed to read output of testcase %d: %s", tc.Id, err.Error()))
 	}
 
 	if runResult.ExecResult.StatusCode != sandbox.RUN_SUCCESS {
 		goto Send
 	}
 
-	accepted = grader.Grade([]byte(tc.Out), runResult.Output)
+	accepted, err = handler.GradeOutput(t.grader, tc, runResult)
+	if err != nil {
+		t.logger.Log(logger.ERROR, fmt.Sprintf("Error while grading testcase %d: %s", tc.Id, err.Error()))
+		judgeResultCode = handler.SERVER_ERROR
+		goto Send
+	}
 
 	if !accepted {
 		judgeResultCode = handler.WRONG_ANSWER
+		// 히든 테스트케이스의 정답은 diff로도 노출하지 않고, 디스크의 큰 테스트케이스는 diff를 계산하지 않음
+		if !tc.Hidden && !tc.OnDisk() {
+			res.Mismatch = t.grader.Diff([]byte(tc.Out), runResult.Output)
+		}
 	}
 
 Send:
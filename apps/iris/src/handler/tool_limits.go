@@ -1,10 +1,6 @@
 package handler
 
-import (
-	"fmt"
-	"os"
-	"strconv"
-)
+import "github.com/skkuding/codedang/apps/iris/src/utils"
 
 const (
 	ToolTimeLimitEnv       = "POLYGON_TOOL_TIME_LIMIT_MS"
@@ -23,11 +19,11 @@ type ToolExecutionLimits struct {
 }
 
 func ToolLimitsFromEnv() (ToolExecutionLimits, error) {
-	timeLimit, err := positiveIntFromEnv(ToolTimeLimitEnv, DefaultToolTimeLimit)
+	timeLimit, err := utils.GetenvPositiveInt(ToolTimeLimitEnv, DefaultToolTimeLimit)
 	if err != nil {
 		return ToolExecutionLimits{}, err
 	}
-	memoryLimit, err := positiveIntFromEnv(ToolMemoryLimitEnv, DefaultToolMemoryLimit)
+	memoryLimit, err := utils.GetenvPositiveInt(ToolMemoryLimitEnv, DefaultToolMemoryLimit)
 	if err != nil {
 		return ToolExecutionLimits{}, err
 	}
@@ -38,14 +34,14 @@ func WorkerCountFromEnv(name string, total, fallback int) (int, error) {
 	if total <= 0 {
 		return 0, nil
 	}
-	workers, err := positiveIntFromEnv(name, fallback)
+	workers, err := utils.GetenvPositiveInt(name, fallback)
 	if err != nil {
 		return 0, err
 	}
 	if workers > total {
 		workers = total
 	}
-	maxWorkers, err := positiveIntFromEnv(ToolMaxWorkersEnv, DefaultToolMaxWorkers)
+	maxWorkers, err := utils.GetenvPositiveInt(ToolMaxWorkersEnv, DefaultToolMaxWorkers)
 	if err != nil {
 		return 0, err
 	}
@@ -56,25 +52,5 @@ func WorkerCountFromEnv(name string, total, fallback int) (int, error) {
 }
 
 func RetryCountFromEnv(name string, fallback int) (int, error) {
-	raw := os.Getenv(name)
-	if raw == "" {
-		return fallback, nil
-	}
-	value, err := strconv.Atoi(raw)
-	if err != nil || value < 0 {
-		return 0, fmt.Errorf("%s must be a non-negative integer", name)
-	}
-	return value, nil
-}
-
-func positiveIntFromEnv(name string, fallback int) (int, error) {
-	raw := os.Getenv(name)
-	if raw == "" {
-		return fallback, nil
-	}
-	value, err := strconv.Atoi(raw)
-	if err != nil || value <= 0 {
-		return 0, fmt.Errorf("%s must be a positive integer", name)
-	}
-	return value, nil
+	return utils.GetenvNonNegativeInt(name, fallback)
 }
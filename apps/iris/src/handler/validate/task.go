@@ -184,6 +184,7 @@ func (t *Task) validateTestcase(
 		TimeLimit:   limits.TimeLimit,
 		MemoryLimit: limits.MemoryLimit,
 		ExtraArgs:   []string{},
+		InputPath:   element.InPath,
 	}, []byte(element.In))
 
 	if runErr != nil {
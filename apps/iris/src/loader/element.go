@@ -1,5 +1,11 @@
 package loader
 
+import (
+	"io"
+	"os"
+	"strings"
+)
+
 type ElementIn struct {
 	// Id is the in-flight generation index. PostgreSQL assigns the persisted ID.
 	Id        int    `json:"id"`
@@ -14,4 +20,51 @@ type ElementOut struct {
 	In     string `json:"in"`
 	Out    string `json:"out"`
 	Hidden bool   `json:"hidden"`
+	// Subtask is the id of the subtask the testcase belongs to
+	Subtask int `json:"subtask,omitempty"`
+	// InPath and OutPath are set instead of In and Out for testcases too large
+	// to be kept in memory. They name read-only files on the local disk.
+	InPath  string `json:"inPath,omitempty"`
+	OutPath string `json:"outPath,omitempty"`
+}
+
+// OnDisk reports whether the input or the answer is only available as a file.
+func (e ElementOut) OnDisk() bool {
+	return e.InPath != "" || e.OutPath != ""
+}
+
+// OpenIn streams the input wherever it is stored.
+func (e ElementOut) OpenIn() (io.ReadCloser, error) {
+	return open(e.In, e.InPath)
+}
+
+// OpenOut streams the answer wherever it is stored.
+func (e ElementOut) OpenOut() (io.ReadCloser, error) {
+	return open(e.Out, e.OutPath)
+}
+
+// ReadIn loads the input into memory. Prefer OpenIn or InPath for testcases
+// on disk.
+func (e ElementOut) ReadIn() ([]byte, error) {
+	return read(e.In, e.InPath)
+}
+
+// ReadOut loads the answer into memory. Prefer OpenOut or OutPath for
+// testcases on disk.
+func (e ElementOut) ReadOut() ([]byte, error) {
+	return read(e.Out, e.OutPath)
+}
+
+func open(data, path string) (io.ReadCloser, error) {
+	if path == "" {
+		return io.NopCloser(strings.NewReader(data)), nil
+	}
+	return os.Open(path)
+}
+
+func read(data, path string) ([]byte, error) {
+	if path == "" {
+		return []byte(data), nil
+	}
+	return os.ReadFile(path)
 }
@@ -129,6 +129,26 @@ func (p *Postgres) Save(ctx context.Context, elements []ElementIn) error {
 	return nil
 }
 
+// Version identifies the current testcases of a problem. Save retires the old
+// rows and inserts new ones, so the newest id and update time change with
+// every save.
+func (p *Postgres) Version(ctx context.Context, key string) (string, error) {
+	const versionQuery = `
+  SELECT count(*), coalesce(max(id), 0), coalesce(max(update_time), 'epoch')
+  FROM public.problem_testcase
+  WHERE problem_id = $1 AND is_outdated = false
+  `
+	var count, maxId int
+	var updateTime time.Time
+	if err := p.client.QueryRowContext(ctx, versionQuery, key).Scan(&count, &maxId, &updateTime); err != nil {
+		return "", fmt.Errorf("failed to get version: %w", err)
+	}
+	if count == 0 {
+		return "", fmt.Errorf("no testcase found for problemId: %s", key)
+	}
+	return fmt.Sprintf("%d-%d-%d", count, maxId, updateTime.UnixNano()), nil
+}
+
 func (p *Postgres) Get(ctx context.Context, key string) ([]ElementOut, error) {
 	const selectQuery = `
   SELECT id, input, output, is_hidden_testcase
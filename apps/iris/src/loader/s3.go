@@ -2,19 +2,29 @@ package loader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"golang.org/x/sync/errgroup"
 )
 
+// versionTagWorkers bounds the concurrent tag requests of Version.
+const versionTagWorkers = 16
+
 type S3reader struct {
 	client *s3.Client
 	bucket string
+	spool  *spool
 }
 
 func NewS3DataSource(bucket string) (*S3reader, error) {
@@ -47,7 +57,74 @@ func NewS3DataSource(bucket string) (*S3reader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot access S3 bucket <%s>: %w", bucket, err)
 	}
-	return &S3reader{client: client, bucket: bucket}, nil
+
+	spool, err := spoolFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &S3reader{client: client, bucket: bucket, spool: spool}, nil
+}
+
+// Version identifies the current testcases of problemId by the keys, ETags
+// and sizes of its objects, and by the tags of the inputs, which Get reads
+// Hidden and Subtask from, without downloading them. It costs a listing plus
+// one GetObjectTagging request per input.
+func (s *S3reader) Version(ctx context.Context, problemId string) (string, error) {
+	output, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(problemId + "/"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list objects: %w", err)
+	}
+	if len(output.Contents) == 0 {
+		return "", fmt.Errorf("no testcases found for problemId: %s", problemId)
+	}
+
+	tags := make([][]types.Tag, len(output.Contents))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(versionTagWorkers)
+	for i, obj := range output.Contents {
+		key := aws.ToString(obj.Key)
+		if !strings.HasSuffix(key, ".in") {
+			continue
+		}
+		group.Go(func() error {
+			output, err := s.client.GetObjectTagging(groupCtx, &s3.GetObjectTaggingInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(key),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get tags for %s: %w", key, err)
+			}
+			tags[i] = output.TagSet
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for i, obj := range output.Contents {
+		writeVersion(hash, obj, tags[i])
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeVersion writes what identifies the content of obj to w. The order of
+// tags does not matter.
+func writeVersion(w io.Writer, obj types.Object, tags []types.Tag) {
+	fmt.Fprintf(w, "%s %s %d", aws.ToString(obj.Key), aws.ToString(obj.ETag), aws.ToInt64(obj.Size))
+	pairs := make([]string, len(tags))
+	for i, tag := range tags {
+		pairs[i] = aws.ToString(tag.Key) + "=" + aws.ToString(tag.Value)
+	}
+	slices.Sort(pairs)
+	for _, pair := range pairs {
+		fmt.Fprintf(w, " %s", strconv.Quote(pair))
+	}
+	fmt.Fprintln(w)
 }
 
 func (s *S3reader) Get(problemId string) ([]ElementOut, error) {
@@ -113,13 +190,13 @@ func (s *S3reader) Get(problemId string) ([]ElementOut, error) {
 			}
 			defer outputOutFile.Body.Close()
 
-			bodyIn, err := io.ReadAll(outputInFile.Body)
+			bodyIn, inPath, err := s.spool.body(problemId, inKey, outputInFile)
 			if err != nil {
 				errChan <- err
 				return
 			}
 
-			bodyOut, err := io.ReadAll(outputOutFile.Body)
+			bodyOut, outPath, err := s.spool.body(problemId, outKey, outputOutFile)
 			if err != nil {
 				errChan <- err
 				return
@@ -141,18 +218,28 @@ func (s *S3reader) Get(problemId string) ([]ElementOut, error) {
 			}
 
 			isHidden := false
+			subtask := 0
 			for _, tag := range outputInTags.TagSet {
-				if *tag.Key == "hidden" && *tag.Value == "true" {
-					isHidden = true
-					break
+				switch *tag.Key {
+				case "hidden":
+					isHidden = *tag.Value == "true"
+				case "subtask":
+					subtask, err = strconv.Atoi(*tag.Value)
+					if err != nil {
+						errChan <- fmt.Errorf("invalid subtask tag for %s: %w", inKey, err)
+						return
+					}
 				}
 			}
 
 			resultChan <- ElementOut{
-				Id:     idInt,
-				In:     string(bodyIn),
-				Out:    string(bodyOut),
-				Hidden: isHidden,
+				Id:      idInt,
+				In:      bodyIn,
+				Out:     bodyOut,
+				Hidden:  isHidden,
+				Subtask: subtask,
+				InPath:  inPath,
+				OutPath: outPath,
 			}
 		}(id)
 	}
@@ -173,5 +260,7 @@ func (s *S3reader) Get(problemId string) ([]ElementOut, error) {
 		return nil, fmt.Errorf("errors occurred while processing testcases: %v", errs)
 	}
 
+	// 이전 버전의 파일 정리에 실패해도 채점에는 지장이 없음
+	_ = s.spool.prune(problemId, results)
 	return results, nil
 }
@@ -0,0 +1,26 @@
+package loader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteVersion(t *testing.T) {
+	obj := types.Object{Key: aws.String("1/1.in"), ETag: aws.String(`"abc"`), Size: aws.Int64(3)}
+	version := func(tags ...types.Tag) string {
+		var buf bytes.Buffer
+		writeVersion(&buf, obj, tags)
+		return buf.String()
+	}
+	hidden := types.Tag{Key: aws.String("hidden"), Value: aws.String("true")}
+	subtask := types.Tag{Key: aws.String("subtask"), Value: aws.String("2")}
+
+	assert.Equal(t, "1/1.in \"abc\" 3\n", version())
+	assert.NotEqual(t, version(), version(hidden), "retagging a testcase must change the version")
+	assert.NotEqual(t, version(hidden), version(hidden, types.Tag{Key: aws.String("subtask"), Value: aws.String("1")}))
+	assert.Equal(t, version(hidden, subtask), version(subtask, hidden))
+}
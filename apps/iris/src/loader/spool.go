@@ -0,0 +1,277 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	// Objects at least StreamThresholdEnv MB large are streamed to files
+	// under SpoolDirEnv instead of being read into memory. An empty spool
+	// dir keeps every testcase in memory.
+	SpoolDirEnv            = "TESTCASE_SPOOL_DIR"
+	StreamThresholdEnv     = "TESTCASE_STREAM_THRESHOLD_MB"
+	DefaultStreamThreshold = 8
+	// The spool dir holds at most SpoolMaxEnv MB (0 for no limit). Files
+	// are removed only once unused for SpoolRetentionEnv, which must cover
+	// the longest message timeout as judges read them until they finish.
+	SpoolMaxEnv             = "TESTCASE_SPOOL_MAX_MB"
+	DefaultSpoolMax         = 10 * 1024
+	SpoolRetentionEnv       = "TESTCASE_SPOOL_RETENTION_MS"
+	DefaultSpoolRetentionMS = 15 * 60 * 1000
+)
+
+// spool keeps large testcase objects on the local disk, one directory per
+// problem. Files are named after the ETag of their object, so a file that is
+// already there is reused as is. The modification time of a file is the last
+// time it was handed out, see Touch.
+type spool struct {
+	dir       string
+	threshold int64
+	capacity  int64
+	retention time.Duration
+
+	// mu guards pending, the size of the files being streamed
+	mu      sync.Mutex
+	pending int64
+}
+
+func spoolFromEnv() (*spool, error) {
+	dir := os.Getenv(SpoolDirEnv)
+	if dir == "" {
+		return nil, nil
+	}
+	threshold, err := utils.GetenvNonNegativeInt(StreamThresholdEnv, DefaultStreamThreshold)
+	if err != nil {
+		return nil, err
+	}
+	capacity, err := utils.GetenvNonNegativeInt(SpoolMaxEnv, DefaultSpoolMax)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := utils.GetenvPositiveInt(SpoolRetentionEnv, DefaultSpoolRetentionMS)
+	if err != nil {
+		return nil, err
+	}
+	// the sandbox runs checkers as an unprivileged user, which must be able
+	// to read the spooled files
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	return &spool{
+		dir:       dir,
+		threshold: int64(threshold) * 1024 * 1024,
+		capacity:  int64(capacity) * 1024 * 1024,
+		retention: time.Duration(retention) * time.Millisecond,
+	}, nil
+}
+
+// body returns the content of object either in memory or, if it is large,
+// as the path of a file holding it. It is kept in memory as well if the
+// spool is full of files still in use.
+func (s *spool) body(problemId, key string, object *s3.GetObjectOutput) (data, path string, err error) {
+	size := aws.ToInt64(object.ContentLength)
+	if s == nil || size < s.threshold {
+		return readBody(object)
+	}
+
+	dir := filepath.Join(s.dir, filepath.Base(problemId))
+	path = filepath.Join(dir, spoolName(key, aws.ToString(object.ETag)))
+	if info, err := os.Stat(path); err == nil && info.Size() == size {
+		touch(path)
+		return "", path, nil
+	}
+
+	reserved, err := s.reserve(size)
+	if err != nil {
+		return "", "", err
+	}
+	if !reserved {
+		return readBody(object)
+	}
+	defer s.release(size)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	file, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, object.Body); err != nil {
+		file.Close()
+		return "", "", fmt.Errorf("failed to stream %s: %w", key, err)
+	}
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return "", "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", "", fmt.Errorf("failed to store spool file: %w", err)
+	}
+	return "", path, nil
+}
+
+func readBody(object *s3.GetObjectOutput) (data, path string, err error) {
+	body, err := io.ReadAll(object.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return string(body), "", nil
+}
+
+// reserve makes room for a file of size by removing the files unused for
+// longest, and reports whether there is room.
+func (s *spool) reserve(size int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capacity == 0 {
+		return true, nil
+	}
+	if size > s.capacity {
+		return false, nil
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	used := s.pending
+	err := filepath.WalkDir(s.dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		// files being streamed are counted as pending
+		if !strings.HasPrefix(entry.Name(), ".tmp-") {
+			used += info.Size()
+			files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to measure spool dir: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if used+size <= s.capacity || !s.expired(f.modTime) {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		used -= f.size
+	}
+	if used+size > s.capacity {
+		return false, nil
+	}
+	s.pending += size
+	return true, nil
+}
+
+func (s *spool) release(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capacity != 0 {
+		s.pending -= size
+	}
+}
+
+// expired reports whether a file last handed out at modTime may be removed,
+// i.e. no judge can be reading it anymore.
+func (s *spool) expired(modTime time.Time) bool {
+	return time.Since(modTime) >= s.retention
+}
+
+// Touch marks the spooled files of elements as in use, so that they are not
+// removed while judges read them.
+func Touch(elements []ElementOut) {
+	for _, element := range elements {
+		for _, path := range []string{element.InPath, element.OutPath} {
+			if path != "" {
+				touch(path)
+			}
+		}
+	}
+}
+
+func touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// prune removes the files of problemId that belong to none of elements,
+// i.e. those of testcases that were replaced or deleted, once judges that
+// started before can no longer be reading them.
+func (s *spool) prune(problemId string, elements []ElementOut) error {
+	if s == nil {
+		return nil
+	}
+	dir := filepath.Join(s.dir, filepath.Base(problemId))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var keep []string
+	for _, element := range elements {
+		keep = append(keep, filepath.Base(element.InPath), filepath.Base(element.OutPath))
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".tmp-") || slices.Contains(keep, entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !s.expired(info.ModTime()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// spoolName is e.g. "3.1f2e3d4c.in" for the object "<problemId>/3.in".
+func spoolName(key, etag string) string {
+	base := filepath.Base(key)
+	ext := filepath.Ext(base)
+	sum := sha256.Sum256([]byte(etag))
+	return strings.TrimSuffix(base, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+}
@@ -0,0 +1,151 @@
+package loader
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func object(body, etag string) *s3.GetObjectOutput {
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: aws.Int64(int64(len(body))),
+		ETag:          aws.String(etag),
+	}
+}
+
+func TestSpool(t *testing.T) {
+	t.Run("keeps small objects in memory", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4}
+
+		data, path, err := s.body("1", "1/1.in", object("abc", `"e1"`))
+		require.NoError(t, err)
+		assert.Equal(t, "abc", data)
+		assert.Empty(t, path)
+	})
+
+	t.Run("streams large objects to disk", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4}
+
+		data, path, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		assert.Empty(t, data)
+		assert.Equal(t, filepath.Join(s.dir, "1"), filepath.Dir(path))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef", string(content))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+	})
+
+	t.Run("reuses files of the same ETag", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4}
+
+		_, first, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		_, second, err := s.body("1", "1/1.in", object("ghijkl", `"e1"`))
+		require.NoError(t, err)
+		_, third, err := s.body("1", "1/1.in", object("ghijkl", `"e2"`))
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, third)
+		content, err := os.ReadFile(second)
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef", string(content))
+	})
+
+	t.Run("prunes replaced testcases once unused", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4, retention: time.Minute}
+
+		_, stale, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		_, current, err := s.body("1", "1/1.in", object("ghijkl", `"e2"`))
+		require.NoError(t, err)
+
+		// a judge that started before the update may still read it
+		require.NoError(t, s.prune("1", []ElementOut{{Id: 1, InPath: current}}))
+		assert.FileExists(t, stale)
+
+		age(t, stale, time.Minute)
+		require.NoError(t, s.prune("1", []ElementOut{{Id: 1, InPath: current}}))
+		assert.NoFileExists(t, stale)
+		assert.FileExists(t, current)
+	})
+
+	t.Run("evicts the files unused for longest when full", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4, capacity: 12, retention: time.Minute}
+
+		_, older, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		_, old, err := s.body("2", "2/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		age(t, older, 2*time.Minute)
+		age(t, old, time.Minute)
+
+		_, path, err := s.body("3", "3/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+		assert.NoFileExists(t, older)
+		assert.FileExists(t, old)
+	})
+
+	t.Run("keeps objects in memory when full of files in use", func(t *testing.T) {
+		s := &spool{dir: t.TempDir(), threshold: 4, capacity: 12, retention: time.Minute}
+
+		_, first, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		_, second, err := s.body("2", "2/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		age(t, first, 2*time.Minute)
+		Touch([]ElementOut{{InPath: first}})
+
+		data, path, err := s.body("3", "3/1.in", object("ghijkl", `"e1"`))
+		require.NoError(t, err)
+		assert.Equal(t, "ghijkl", data)
+		assert.Empty(t, path)
+		assert.FileExists(t, first)
+		assert.FileExists(t, second)
+	})
+
+	t.Run("nil spool keeps everything in memory", func(t *testing.T) {
+		var s *spool
+
+		data, path, err := s.body("1", "1/1.in", object("abcdef", `"e1"`))
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef", data)
+		assert.Empty(t, path)
+		assert.NoError(t, s.prune("1", nil))
+	})
+}
+
+// age makes path look unused for d.
+func age(t *testing.T, path string, d time.Duration) {
+	modTime := time.Now().Add(-d)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestElementOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "1.in")
+	require.NoError(t, os.WriteFile(path, []byte("on disk"), 0o644))
+	element := ElementOut{Out: "in memory", InPath: path}
+
+	assert.True(t, element.OnDisk())
+	in, err := element.ReadIn()
+	require.NoError(t, err)
+	assert.Equal(t, "on disk", string(in))
+	out, err := element.ReadOut()
+	require.NoError(t, err)
+	assert.Equal(t, "in memory", string(out))
+	assert.False(t, ElementOut{In: "1", Out: "1"}.OnDisk())
+}
@@ -0,0 +1,16 @@
+package response
+
+// CancelResponse answers a cancel message. Only the instance that canceled a
+// task of the submission answers, so that instances receiving cancels through
+// a fanout exchange do not report it as not found.
+type CancelResponse struct {
+	MessageId    string `json:"messageId"`
+	SubmissionId int    `json:"submissionId"`
+	Canceled     bool   `json:"canceled"`
+}
+
+func NewCancelResponse(messageID string, submissionID int) *CancelResponse {
+	return &CancelResponse{MessageId: messageID, SubmissionId: submissionID, Canceled: true}
+}
+
+func (r *CancelResponse) Marshal() ([]byte, error) { return JSONMarshal(r) }
@@ -59,6 +59,8 @@ func newEncoder(path constants.MessageType, messageID string, data []byte) (enco
 		return judgeEncoder{messageID: messageID, messageType: constants.Judge}, nil
 	case constants.SpecialJudge:
 		return judgeEncoder{messageID: messageID, messageType: constants.SpecialJudge}, nil
+	case constants.Interactive:
+		return judgeEncoder{messageID: messageID, messageType: constants.Interactive}, nil
 	case constants.Run:
 		return judgeEncoder{messageID: messageID, messageType: constants.Run}, nil
 	case constants.UserTestCase:
@@ -8,6 +8,15 @@ type SubmissionResponse struct {
 	SubmissionId int              `json:"submissionId"`
 	JudgeResults []*JudgeResponse `json:"judgeResults"`
 	Finished     bool             `json:"finished"`
+	// Score and Subtasks are set only for problems scored by subtask
+	Score    *int           `json:"score,omitempty"`
+	Subtasks []SubtaskScore `json:"subtasks,omitempty"`
+}
+
+type SubtaskScore struct {
+	Id       int `json:"id"`
+	Score    int `json:"score"`
+	MaxScore int `json:"maxScore"`
 }
 
 func NewSubmissionResponse(id string, judgeResponses []*JudgeResponse) *SubmissionResponse {
@@ -23,6 +32,16 @@ func NewSubmissionResponse(id string, judgeResponses []*JudgeResponse) *Submissi
 
 func (r *SubmissionResponse) formatJudgeResponse(res *JudgeResponse) {}
 
+// SetScore attaches the subtask scores and their sum to the response.
+func (r *SubmissionResponse) SetScore(subtasks []SubtaskScore) {
+	score := 0
+	for _, subtask := range subtasks {
+		score += subtask.Score
+	}
+	r.Score = &score
+	r.Subtasks = subtasks
+}
+
 func (r *SubmissionResponse) Marshal() []byte {
 
 	if res, err := JSONMarshal(r); err != nil {
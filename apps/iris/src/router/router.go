@@ -2,12 +2,15 @@ package router
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 
 	instrumentation "github.com/skkuding/codedang/apps/iris/src"
 	"github.com/skkuding/codedang/apps/iris/src/common/constants"
 	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/handler/check"
 	"github.com/skkuding/codedang/apps/iris/src/handler/generate"
 	"github.com/skkuding/codedang/apps/iris/src/handler/judge"
 	"github.com/skkuding/codedang/apps/iris/src/handler/run"
@@ -28,6 +31,9 @@ type router struct {
 	runTaskFactory      *run.Factory
 	generateTaskFactory *generate.Factory
 	validateTaskFactory *validate.Factory
+	checkTaskFactory    *check.Factory
+	admission           *handler.Admission
+	cancellations       *handler.Cancellations
 	logger              logger.Logger
 	tracer              trace.Tracer
 }
@@ -43,6 +49,8 @@ func NewRouter(
 	runTaskFactory *run.Factory,
 	generateTaskFactory *generate.Factory,
 	validateTaskFactory *validate.Factory,
+	checkTaskFactory *check.Factory,
+	admission *handler.Admission,
 	logger logger.Logger,
 	tracer trace.Tracer,
 ) Router {
@@ -52,6 +60,9 @@ func NewRouter(
 		runTaskFactory,
 		generateTaskFactory,
 		validateTaskFactory,
+		checkTaskFactory,
+		admission,
+		handler.NewCancellations(),
 		logger,
 		tracer,
 	}
@@ -79,8 +90,13 @@ func (r *router) Route(path constants.MessageType, id string, data []byte, out c
 	var taskErr error
 
 	r.logger.Log(logger.INFO, fmt.Sprintf("%s message received", path))
+	if path == constants.Cancel {
+		r.cancel(id, data, out, ctx)
+		return
+	}
+
 	switch path {
-	case constants.Judge, constants.SpecialJudge:
+	case constants.Judge, constants.SpecialJudge, constants.Interactive:
 		task, taskErr = r.judgeTaskFactory.Create(string(path), data)
 	case constants.Run, constants.UserTestCase:
 		task, taskErr = r.runTaskFactory.Create(string(path), data)
@@ -89,9 +105,7 @@ func (r *router) Route(path constants.MessageType, id string, data []byte, out c
 	case constants.Validate:
 		task, taskErr = r.validateTaskFactory.Create(string(path), data)
 	case constants.Check:
-		// task, taskErr = r.checkTaskFactory.Create(path, data)
-		// TODO: implement check factory
-		taskErr = fmt.Errorf("check handler not implemented yet")
+		task, taskErr = r.checkTaskFactory.Create(string(path), data)
 	default:
 		taskErr = fmt.Errorf("invalid request type: %s", path)
 	}
@@ -107,16 +121,7 @@ func (r *router) Route(path constants.MessageType, id string, data []byte, out c
 
 	if taskErr != nil {
 		r.logger.Log(logger.ERROR, fmt.Sprintf("Error creating task for path %s: %v", path, taskErr))
-		r.errHandle(taskErr)
-		if !sender.Send(handler.ResultMessage{Err: taskErr}) {
-			return
-		}
-		if isSubmissionTask(path) {
-			judgeResponse := response.NewJudgeResponse(id, nil, taskErr)
-			sender.Send(handler.ResultMessage{
-				EncodedResponse: response.NewSubmissionResponse(id, []*response.JudgeResponse{judgeResponse}).Marshal(),
-			}, constants.Submission)
-		}
+		r.sendFailure(sender, path, id, taskErr)
 		return
 	}
 
@@ -126,9 +131,24 @@ func (r *router) Route(path constants.MessageType, id string, data []byte, out c
 		r.logger.Log(logger.INFO, fmt.Sprintf("Task successfully created for path %s with id %s: %s", path, id, task.GetDebugString()))
 	}
 
+	// cancel 메시지로 대기 중이거나 실행 중인 task를 중단할 수 있도록 등록
+	newCtx, unregister := r.cancellations.Register(newCtx, id)
+	defer unregister()
+
+	release, err := r.admission.Acquire(newCtx, handler.TaskClassOf(path))
+	if err != nil {
+		if handler.CanceledByRequest(newCtx) {
+			r.sendFailure(sender, path, id, canceledError())
+			return
+		}
+		r.sendFailure(sender, path, id, handler.NewTaskError("router", handler.SERVER_ERROR, logger.ERROR, fmt.Errorf("task was not admitted: %w", err)))
+		return
+	}
+
 	r.logger.Log(logger.INFO, fmt.Sprintf("Running task for path %s with id %s", path, id))
 	go func() {
 		defer close(taskResultChan)
+		defer release()
 		r.runner.Run(newCtx, id, task, func(result handler.ResultMessage, messageType ...constants.MessageType) {
 			select {
 			case taskResultChan <- taskResult{message: result, messageType: messageType}:
@@ -137,18 +157,79 @@ func (r *router) Route(path constants.MessageType, id string, data []byte, out c
 		})
 	}()
 
+	// finished is set once the final response of the task has been sent
+	finished := false
 	for result := range taskResultChan {
 		r.errHandle(result.message.Err)
 		if !sender.Send(result.message, result.messageType...) {
 			return
 		}
+		finished = finished || !IsSubmissionTask(path) || slices.Contains(result.messageType, constants.Submission)
+	}
+
+	// 취소된 task의 결과는 버려지므로 최종 응답을 대신 보냄
+	if handler.CanceledByRequest(newCtx) && !finished {
+		r.sendFailure(sender, path, id, canceledError())
 	}
 	r.logger.Log(logger.DEBUG, "Router done...")
 }
 
-func isSubmissionTask(path constants.MessageType) bool {
+// cancel aborts the running tasks of the submission in data and answers if
+// there were any. Other instances may be running them, so nothing else is
+// answered. The canceled tasks report CANCELED on their own.
+func (r *router) cancel(id string, data []byte, out chan<- response.Response, ctx context.Context) {
+	var req handler.CancelRequest
+	err := json.Unmarshal(data, &req)
+	if err == nil && req.SubmissionId <= 0 {
+		err = fmt.Errorf("submissionId must be a positive integer")
+	}
+	if err != nil {
+		r.logger.Log(logger.WARN, fmt.Sprintf("invalid cancel request %s: %v", id, err))
+		return
+	}
+	canceled := r.cancellations.Cancel(req.MessageId())
+	r.logger.Log(logger.INFO, fmt.Sprintf("cancel request %s for submission %d: canceled=%t", id, req.SubmissionId, canceled))
+	if !canceled {
+		return
+	}
+
+	message, marshalErr := response.NewCancelResponse(id, req.SubmissionId).Marshal()
+	if marshalErr != nil {
+		r.logger.Log(logger.ERROR, fmt.Sprintf("failed to marshal cancel response %s: %v", id, marshalErr))
+		return
+	}
+	select {
+	case out <- response.Response{Message: message, Type: constants.Cancel}:
+	case <-ctx.Done():
+	}
+}
+
+// sendFailure reports taskErr as the result of the message, followed by a
+// finished submission response for submission tasks.
+func (r *router) sendFailure(sender *response.Sender, path constants.MessageType, id string, taskErr error) {
+	r.errHandle(taskErr)
+	if !sender.Send(handler.ResultMessage{Err: taskErr}) {
+		return
+	}
+	if IsSubmissionTask(path) {
+		judgeResponse := response.NewJudgeResponse(id, nil, taskErr)
+		sender.Send(handler.ResultMessage{
+			EncodedResponse: response.NewSubmissionResponse(id, []*response.JudgeResponse{judgeResponse}).Marshal(),
+		}, constants.Submission)
+	}
+}
+
+func canceledError() error {
+	taskErr := handler.NewTaskError("router", handler.CANCELED, logger.INFO, handler.ErrCanceledByRequest)
+	taskErr.UserMsg = "Canceled by request"
+	return taskErr
+}
+
+// IsSubmissionTask reports whether messages of path finish with a submission
+// response.
+func IsSubmissionTask(path constants.MessageType) bool {
 	switch path {
-	case constants.Judge, constants.SpecialJudge, constants.Run, constants.UserTestCase:
+	case constants.Judge, constants.SpecialJudge, constants.Interactive, constants.Run, constants.UserTestCase:
 		return true
 	default:
 		return false
@@ -0,0 +1,256 @@
+package router_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/router/routertest"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/fake"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEnv(t *testing.T) *routertest.Env {
+	return routertest.NewEnv(t, routertest.Testcases{
+		"1": {Elements: []loader.ElementOut{
+			{Id: 1, In: "1 2\n", Out: "1 2\n"},
+			{Id: 2, In: "3 4\n", Out: "3 4\n"},
+			{Id: 3, In: "5 6\n", Out: "5 6\n"},
+		}},
+		"3": {Elements: []loader.ElementOut{
+			{Id: 1, In: "1\n", Out: "2\n"},
+			{Id: 2, In: "3\n", Out: "4\n", Hidden: true},
+		}},
+	})
+}
+
+func judgeRequest(t *testing.T, extra map[string]any) []byte {
+	req := map[string]any{
+		"code":        "int main() {}",
+		"language":    "C",
+		"problemId":   1,
+		"timeLimit":   1000,
+		"memoryLimit": 256 * 1024 * 1024,
+	}
+	for key, value := range extra {
+		req[key] = value
+	}
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	return data
+}
+
+func TestRouteGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   constants.MessageType
+		data   func(t *testing.T) []byte
+		script func(s *fake.Sandbox)
+	}{
+		{
+			name: "judge_accepted",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, nil) },
+		},
+		{
+			name: "judge_wrong_answer",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, nil) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(fake.ByOrder(map[int]fake.Result{1: {Output: []byte("4 3\n")}}, fake.Echo))
+			},
+		},
+		{
+			name: "judge_out_of_order",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, nil) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(func(req sandbox.RunRequest, input []byte) fake.Result {
+					return fake.Result{Output: input, CpuTime: 10 * req.Order, Latency: time.Duration(3-req.Order) * 5 * time.Millisecond}
+				})
+			},
+		},
+		{
+			name: "judge_runtime_error",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, map[string]any{"stopOnNotAccepted": true}) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(fake.Respond(
+					fake.Result{Output: []byte("1 2\n"), CpuTime: 3, RealTime: 4, Memory: 1024},
+					fake.Result{Status: sandbox.SEGMENTATION_FAULT_ERROR, Signal: 11, Stderr: []byte("main: stack smashing detected")},
+				))
+			},
+		},
+		{
+			name: "judge_hidden_stderr",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, map[string]any{"problemId": 3}) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(func(_ sandbox.RunRequest, input []byte) fake.Result {
+					return fake.Result{Status: sandbox.RUNTIME_ERROR, ExitCode: 1, Stderr: append([]byte("read "), input...)}
+				})
+			},
+		},
+		{
+			name: "judge_time_limit_exceeded",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, nil) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(fake.ByOrder(map[int]fake.Result{2: {Status: sandbox.CPU_TIME_LIMIT_EXCEEDED, CpuTime: 1001}}, fake.Echo))
+			},
+		},
+		{
+			name: "judge_output_limit_exceeded",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, map[string]any{"outputLimit": 1024}) },
+			script: func(s *fake.Sandbox) {
+				s.OnRun(func(req sandbox.RunRequest, _ []byte) fake.Result {
+					if req.OutputLimit != 1024 {
+						return fake.Result{Status: sandbox.SERVER_ERROR}
+					}
+					return fake.Result{Status: sandbox.OUTPUT_LIMIT_EXCEEDED, Signal: 25, Output: []byte("yes\nyes\n")}
+				})
+			},
+		},
+		{
+			name: "interactive_output_limit_exceeded",
+			path: constants.Interactive,
+			data: func(t *testing.T) []byte {
+				return judgeRequest(t, map[string]any{"outputLimit": 1024, "interactorCode": "int main() {}", "interactorLanguage": "C"})
+			},
+			script: func(s *fake.Sandbox) {
+				s.OnInteract(func(solution sandbox.RunRequest, _ sandbox.RunRequest) (fake.Result, fake.Result) {
+					if solution.OutputLimit != 1024 {
+						return fake.Result{Status: sandbox.SERVER_ERROR}, fake.Result{}
+					}
+					return fake.Result{Status: sandbox.OUTPUT_LIMIT_EXCEEDED, Signal: 25}, fake.Result{}
+				})
+			},
+		},
+		{
+			name: "special_judge_hidden_checker_message",
+			path: constants.SpecialJudge,
+			data: func(t *testing.T) []byte {
+				return judgeRequest(t, map[string]any{"problemId": 3, "checkerCode": "int main() {}", "checkerLanguage": "C"})
+			},
+			script: func(s *fake.Sandbox) {
+				s.OnRun(func(req sandbox.RunRequest, input []byte) fake.Result {
+					if len(req.ExtraArgs) == 0 {
+						return fake.Echo(req, input)
+					}
+					return fake.Result{Status: sandbox.RUNTIME_ERROR, ExitCode: 1, Stderr: []byte("wrong answer expected 2, found 1")}
+				})
+			},
+		},
+		{
+			name: "judge_compile_error",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, nil) },
+			script: func(s *fake.Sandbox) {
+				s.OnCompile(func(sandbox.CompileRequest) fake.Result {
+					return fake.Result{Status: sandbox.RUNTIME_ERROR, ExitCode: 1, Stderr: []byte("main.c:1: error: expected ';'")}
+				})
+			},
+		},
+		{
+			name: "judge_unknown_problem",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return judgeRequest(t, map[string]any{"problemId": 2}) },
+		},
+		{
+			name: "run_user_testcase",
+			path: constants.UserTestCase,
+			data: func(t *testing.T) []byte {
+				return judgeRequest(t, map[string]any{"userTestcases": []loader.ElementOut{{Id: 1, In: "hello\n", Out: "hello\n"}}})
+			},
+		},
+		{
+			name: "validate_invalid_testcase",
+			path: constants.Validate,
+			data: func(t *testing.T) []byte {
+				return []byte(`{"problemId": 1, "language": "C", "validatorCode": "int main() {}"}`)
+			},
+			script: func(s *fake.Sandbox) {
+				s.OnRun(fake.ByOrder(map[int]fake.Result{2: {Status: sandbox.RUNTIME_ERROR, ExitCode: 3, Stderr: []byte("expected two integers")}}, fake.Respond(fake.Result{})))
+			},
+		},
+		{
+			name: "invalid_request",
+			path: constants.Judge,
+			data: func(t *testing.T) []byte { return []byte(`{"code": ""}`) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newEnv(t)
+			if tt.script != nil {
+				tt.script(env.Sandbox)
+			}
+
+			responses := env.Route(tt.path, "1", tt.data(t))
+
+			routertest.AssertGolden(t, filepath.Join("testdata", tt.name+".golden"), responses)
+		})
+	}
+}
+
+func TestRouteRecordsSandboxCalls(t *testing.T) {
+	env := newEnv(t)
+
+	env.Route(constants.Judge, "1", judgeRequest(t, nil))
+
+	compiles := env.Sandbox.Compiles()
+	require.Len(t, compiles, 1)
+	assert.Equal(t, sandbox.C, compiles[0].Language)
+
+	inputs := map[int]string{}
+	for _, run := range env.Sandbox.Runs() {
+		inputs[run.Request.Order] = string(run.Input)
+	}
+	assert.Equal(t, map[int]string{0: "1 2\n", 1: "3 4\n", 2: "5 6\n"}, inputs)
+}
+
+func TestRouteCancel(t *testing.T) {
+	t.Run("answers only when it canceled a task", func(t *testing.T) {
+		env := newEnv(t)
+		running := make(chan struct{})
+		release := make(chan struct{})
+		var once sync.Once
+		env.Sandbox.OnRun(func(req sandbox.RunRequest, input []byte) fake.Result {
+			once.Do(func() { close(running) })
+			<-release
+			return fake.Echo(req, input)
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			env.Route(constants.Judge, "1", judgeRequest(t, nil))
+		}()
+		<-running
+
+		responses := env.Route(constants.Cancel, "cancel-1", []byte(`{"submissionId": 1}`))
+		close(release)
+		<-done
+
+		require.Len(t, responses, 1)
+		assert.JSONEq(t, `{"messageId": "cancel-1", "submissionId": 1, "canceled": true}`, string(responses[0].Message))
+	})
+
+	t.Run("stays silent when nothing runs", func(t *testing.T) {
+		env := newEnv(t)
+
+		assert.Empty(t, env.Route(constants.Cancel, "cancel-1", []byte(`{"submissionId": 1}`)))
+		assert.Empty(t, env.Route(constants.Cancel, "cancel-2", []byte(`{"submissionId": 0}`)))
+	})
+}
+
+var _ testcase.TestcaseReader = routertest.Testcases{}
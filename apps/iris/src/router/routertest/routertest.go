@@ -0,0 +1,180 @@
+// Package routertest wires a router with in-process fakes and compares the
+// responses of router.Route with golden files, so that message to response
+// flows can be tested without libjudger, S3 or the database.
+package routertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
+	"github.com/skkuding/codedang/apps/iris/src/handler"
+	"github.com/skkuding/codedang/apps/iris/src/handler/check"
+	"github.com/skkuding/codedang/apps/iris/src/handler/generate"
+	"github.com/skkuding/codedang/apps/iris/src/handler/judge"
+	"github.com/skkuding/codedang/apps/iris/src/handler/run"
+	"github.com/skkuding/codedang/apps/iris/src/handler/validate"
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/router"
+	"github.com/skkuding/codedang/apps/iris/src/router/response"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/fake"
+	"github.com/skkuding/codedang/apps/iris/src/service/testcase"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// UpdateGoldenEnv rewrites the golden files with the actual responses when
+// set, e.g. IRIS_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "IRIS_UPDATE_GOLDEN"
+
+// testLogger writes the logs of a test to its output, which go test shows
+// only if the test fails or runs with -v.
+type testLogger struct{ t testing.TB }
+
+func (l testLogger) Log(level logger.Level, msg string) {
+	l.t.Helper()
+	l.t.Logf("%s: %s", level, msg)
+}
+
+func (l testLogger) LogWithContext(level logger.Level, msg string, _ context.Context) {
+	l.t.Helper()
+	l.t.Logf("%s: %s", level, msg)
+}
+
+func (l testLogger) Panic(msg string) { l.t.Fatal(msg) }
+
+// Testcases serves the testcases of each problem id from memory and stores
+// generated ones, in place of the testcase manager.
+type Testcases map[string]testcase.Testcase
+
+func (tc Testcases) GetTestcase(_ context.Context, problemId string, _ testcase.TestcaseFilterCode) (testcase.Testcase, error) {
+	testcases, ok := tc[problemId]
+	if !ok {
+		return testcase.Testcase{}, fmt.Errorf("no testcases for problem %s", problemId)
+	}
+	return testcases, nil
+}
+
+func (tc Testcases) SaveTestcase(_ context.Context, problemId string, _ bool, data []loader.ElementIn) error {
+	elements := make([]loader.ElementOut, len(data))
+	for i, element := range data {
+		elements[i] = loader.ElementOut{Id: i + 1, In: element.In, Out: element.Out}
+	}
+	tc[problemId] = testcase.Testcase{Elements: elements}
+	return nil
+}
+
+// Env is a router running tasks in the fake Sandbox, with Testcases as the
+// testcase store and a temporary result directory.
+type Env struct {
+	Router    router.Router
+	Sandbox   *fake.Sandbox
+	Testcases Testcases
+	File      file.FileManager
+}
+
+// NewEnv wires every task factory the way main does, without a CPU pool and
+// admission limits.
+func NewEnv(t testing.TB, testcases Testcases) *Env {
+	t.Helper()
+	fileManager := file.NewFileManager(t.TempDir())
+	sandbox := fake.New(fileManager)
+	tracer := noop.NewTracerProvider().Tracer("")
+	log := testLogger{t}
+
+	return &Env{
+		Router: router.NewRouter(
+			handler.NewTaskRunner(sandbox, fileManager, log, tracer),
+			judge.NewFactory(testcases, sandbox, fileManager, nil, log, tracer),
+			run.NewFactory(testcases, sandbox, log, tracer),
+			generate.NewFactory(testcases, sandbox, log),
+			validate.NewFactory(testcases, sandbox, log),
+			check.NewFactory(testcases, sandbox, fileManager, log),
+			nil,
+			log,
+			tracer,
+		),
+		Sandbox:   sandbox,
+		Testcases: testcases,
+		File:      fileManager,
+	}
+}
+
+// Route routes one message and returns every response it produced.
+func (e *Env) Route(path constants.MessageType, id string, data []byte) []response.Response {
+	return Route(e.Router, path, id, data)
+}
+
+// Route routes one message through r and collects its responses until Route
+// returns.
+func Route(r router.Router, path constants.MessageType, id string, data []byte) []response.Response {
+	out := make(chan response.Response)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Route(path, id, data, out, context.Background())
+	}()
+
+	var responses []response.Response
+	for {
+		select {
+		case res := <-out:
+			responses = append(responses, res)
+		case <-done:
+			return responses
+		}
+	}
+}
+
+type goldenResponse struct {
+	Type    constants.MessageType `json:"type"`
+	Message json.RawMessage       `json:"message"`
+}
+
+// AssertGolden compares responses with the golden file at path, and rewrites
+// the file instead if UpdateGoldenEnv is set.
+func AssertGolden(t testing.TB, path string, responses []response.Response) {
+	t.Helper()
+	actual, err := MarshalGolden(responses)
+	if err != nil {
+		t.Fatalf("marshaling responses: %v", err)
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("responses differ from %s (set %s=1 to update it)\nexpected:\n%s\nactual:\n%s", path, UpdateGoldenEnv, expected, actual)
+	}
+}
+
+// MarshalGolden formats responses as an indented JSON array of their types
+// and messages.
+func MarshalGolden(responses []response.Response) ([]byte, error) {
+	golden := make([]goldenResponse, len(responses))
+	for i, res := range responses {
+		golden[i] = goldenResponse{Type: res.Type, Message: res.Message}
+	}
+	data, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
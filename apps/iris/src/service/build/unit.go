@@ -129,3 +129,47 @@ func (bu *BuildUnit) Run(
 	req.Language = bu.ParsedLang
 	return sandboxService.Run(req, input)
 }
+
+// RunIn is Run with the run artifacts written to runDir, a directory the
+// caller owns exclusively. The unit directory is then only read, so RunIn
+// calls with distinct run dirs do not lock the unit and may overlap.
+// An empty runDir falls back to Run.
+func (bu *BuildUnit) RunIn(
+	sandboxService sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs],
+	runDir string,
+	req sandbox.RunRequest,
+	input []byte,
+) (sandbox.RunResult, error) {
+	if runDir == "" {
+		return bu.Run(sandboxService, req, input)
+	}
+
+	req.Dir = bu.Dir
+	req.OutputDir = runDir
+	req.Language = bu.ParsedLang
+	return sandboxService.Run(req, input)
+}
+
+// RunInteractive runs the solution unit against the interactor unit with
+// their stdin/stdout cross-wired. Both units are locked for the whole run.
+func RunInteractive(
+	sandboxService sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs],
+	solution *BuildUnit,
+	solutionReq sandbox.RunRequest,
+	interactor *BuildUnit,
+	interactorReq sandbox.RunRequest,
+) (sandbox.InteractiveRunResult, error) {
+	if solution == interactor {
+		return sandbox.InteractiveRunResult{}, fmt.Errorf("solution and interactor must be different build units")
+	}
+	solution.runMu.Lock()
+	defer solution.runMu.Unlock()
+	interactor.runMu.Lock()
+	defer interactor.runMu.Unlock()
+
+	solutionReq.Dir = solution.Dir
+	solutionReq.Language = solution.ParsedLang
+	interactorReq.Dir = interactor.Dir
+	interactorReq.Language = interactor.ParsedLang
+	return sandboxService.RunInteractive(solutionReq, interactorReq)
+}
@@ -1,6 +1,7 @@
 package build
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -14,7 +15,9 @@ type blockingSandbox struct {
 	firstEntered  chan struct{}
 	secondEntered chan struct{}
 	releaseFirst  chan struct{}
+	mu            sync.Mutex
 	calls         int
+	outputDirs    []string
 }
 
 func newBlockingSandbox() *blockingSandbox {
@@ -25,9 +28,14 @@ func newBlockingSandbox() *blockingSandbox {
 	}
 }
 
-func (s *blockingSandbox) Run(_ sandbox.RunRequest, _ []byte) (sandbox.RunResult, error) {
+func (s *blockingSandbox) Run(req sandbox.RunRequest, _ []byte) (sandbox.RunResult, error) {
+	s.mu.Lock()
 	s.calls++
-	switch s.calls {
+	calls := s.calls
+	s.outputDirs = append(s.outputDirs, req.OutputDir)
+	s.mu.Unlock()
+
+	switch calls {
 	case 1:
 		close(s.firstEntered)
 		<-s.releaseFirst
@@ -37,6 +45,10 @@ func (s *blockingSandbox) Run(_ sandbox.RunRequest, _ []byte) (sandbox.RunResult
 	return sandbox.RunResult{}, nil
 }
 
+func (*blockingSandbox) RunInteractive(sandbox.RunRequest, sandbox.RunRequest) (sandbox.InteractiveRunResult, error) {
+	return sandbox.InteractiveRunResult{}, nil
+}
+
 func (*blockingSandbox) Compile(sandbox.CompileRequest) (sandbox.CompileResult, error) {
 	return sandbox.CompileResult{}, nil
 }
@@ -98,3 +110,36 @@ func TestBuildUnitRunSerializesSameUnit(t *testing.T) {
 	<-done
 	assert.False(t, concurrent, "same BuildUnit entered the sandbox concurrently")
 }
+
+func TestBuildUnitRunInOverlapsWithDistinctRunDirs(t *testing.T) {
+	unit := &BuildUnit{Dir: "solution", ParsedLang: sandbox.CPP}
+	fake := newBlockingSandbox()
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = unit.RunIn(fake, "solution/worker-0", sandbox.RunRequest{Order: 0}, nil)
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-fake.firstEntered:
+	case <-time.After(time.Second):
+		require.FailNow(t, "first run did not enter sandbox")
+	}
+
+	go func() {
+		_, _ = unit.RunIn(fake, "solution/worker-1", sandbox.RunRequest{Order: 1}, nil)
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-fake.secondEntered:
+	case <-time.After(time.Second):
+		require.FailNow(t, "second run was blocked by the first one")
+	}
+
+	close(fake.releaseFirst)
+	<-done
+	<-done
+	assert.Equal(t, []string{"solution/worker-0", "solution/worker-1"}, fake.outputDirs)
+}
@@ -0,0 +1,144 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+)
+
+type Verdict string
+
+const (
+	OK                 Verdict = "ok"
+	WRONG_ANSWER       Verdict = "wrong_answer"
+	PRESENTATION_ERROR Verdict = "presentation_error"
+	FAIL               Verdict = "fail"
+)
+
+// testlib exit codes (see lib/testlib.h)
+const (
+	okExitCode   = 0
+	waExitCode   = 1
+	peExitCode   = 2
+	dirtExitCode = 4
+)
+
+type Result struct {
+	Verdict    Verdict
+	Message    string
+	ExecResult sandbox.ExecResult
+}
+
+// Checker runs a compiled testlib checker as `checker <input> <output> <answer>`.
+type Checker struct {
+	unit    *build.BuildUnit
+	sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file    file.FileManager
+}
+
+func NewChecker(
+	unit *build.BuildUnit,
+	sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs],
+	file file.FileManager,
+) *Checker {
+	return &Checker{unit: unit, sandbox: sandbox, file: file}
+}
+
+// Check writes input, output and answer into the checker's build directory and
+// runs the checker on them. The returned error is only set when the sandbox
+// itself failed; a crashing checker is reported as FAIL.
+func (c *Checker) Check(req sandbox.RunRequest, input, output, answer []byte) (Result, error) {
+	paths := make([]string, 0, 3)
+	for _, f := range []struct {
+		ext  string
+		data []byte
+	}{{"in", input}, {"out", output}, {"ans", answer}} {
+		path, err := c.Stage(req.Order, f.ext, f.data)
+		if err != nil {
+			return Result{}, err
+		}
+		paths = append(paths, path)
+	}
+	return c.CheckFiles(req, paths[0], paths[1], paths[2])
+}
+
+// Stage writes one checker file (ext is "in", "out" or "ans") into the
+// checker's build directory and returns its path.
+func (c *Checker) Stage(order int, ext string, data []byte) (string, error) {
+	if c.unit == nil || c.unit.Dir == "" {
+		return "", fmt.Errorf("checker build unit is not set up")
+	}
+	path := c.file.MakeFilePath(c.unit.Dir, fmt.Sprintf("checker-%d.%s", order, ext)).String()
+	if err := c.file.CreateFile(path, string(data)); err != nil {
+		return "", fmt.Errorf("writing checker %s file: %w", ext, err)
+	}
+	return path, nil
+}
+
+// CheckFiles is Check for files that are already on disk, such as testcases
+// too large to be kept in memory and the output file of the run. The sandbox
+// user must be able to read them.
+func (c *Checker) CheckFiles(req sandbox.RunRequest, inputPath, outputPath, answerPath string) (Result, error) {
+	if c.unit == nil || c.unit.Dir == "" {
+		return Result{}, fmt.Errorf("checker build unit is not set up")
+	}
+	req.ExtraArgs = []string{inputPath, outputPath, answerPath}
+
+	runResult, err := c.unit.Run(c.sandbox, req, []byte{})
+	if err != nil {
+		return Result{ExecResult: runResult.ExecResult}, fmt.Errorf("running checker: %w", err)
+	}
+
+	return Result{
+		Verdict:    VerdictFromExecResult(runResult.ExecResult),
+		Message:    strings.TrimSpace(string(runResult.ErrOutput)),
+		ExecResult: runResult.ExecResult,
+	}, nil
+}
+
+// CheckTestcase is CheckFiles for a testcase that may be on disk only in
+// part. The side of it that is kept in memory is staged first.
+func (c *Checker) CheckTestcase(req sandbox.RunRequest, tc loader.ElementOut, outputPath string) (Result, error) {
+	inputPath, answerPath := tc.InPath, tc.OutPath
+	var err error
+	if inputPath == "" {
+		if inputPath, err = c.Stage(req.Order, "in", []byte(tc.In)); err != nil {
+			return Result{}, err
+		}
+	}
+	if answerPath == "" {
+		if answerPath, err = c.Stage(req.Order, "ans", []byte(tc.Out)); err != nil {
+			return Result{}, err
+		}
+	}
+	return c.CheckFiles(req, inputPath, outputPath, answerPath)
+}
+
+// VerdictFromExecResult maps the checker's exit status to a verdict following
+// testlib's exit code convention.
+func VerdictFromExecResult(execResult sandbox.ExecResult) Verdict {
+	switch execResult.StatusCode {
+	case sandbox.RUN_SUCCESS, sandbox.RUNTIME_ERROR:
+	default:
+		// checker itself exceeded its limits or crashed in the sandbox
+		return FAIL
+	}
+	if execResult.Signal != 0 {
+		return FAIL
+	}
+
+	switch execResult.ExitCode {
+	case okExitCode:
+		return OK
+	case waExitCode:
+		return WRONG_ANSWER
+	case peExitCode, dirtExitCode:
+		return PRESENTATION_ERROR
+	}
+	return FAIL
+}
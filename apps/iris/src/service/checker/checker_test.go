@@ -0,0 +1,30 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerdictFromExecResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		execResult sandbox.ExecResult
+		want       Verdict
+	}{
+		{"ok", sandbox.ExecResult{StatusCode: sandbox.RUN_SUCCESS}, OK},
+		{"wrong answer", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, ExitCode: 1}, WRONG_ANSWER},
+		{"presentation error", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, ExitCode: 2}, PRESENTATION_ERROR},
+		{"dirt is a presentation error", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, ExitCode: 4}, PRESENTATION_ERROR},
+		{"fail", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, ExitCode: 3}, FAIL},
+		{"killed by signal", sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, Signal: 11}, FAIL},
+		{"time limit", sandbox.ExecResult{StatusCode: sandbox.CPU_TIME_LIMIT_EXCEEDED}, FAIL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, VerdictFromExecResult(tt.execResult))
+		})
+	}
+}
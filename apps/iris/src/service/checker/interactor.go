@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/build"
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+)
+
+type InteractResult struct {
+	Verdict  Verdict
+	Message  string
+	Solution sandbox.ExecResult
+	// SolutionErrOutput is the stderr of the solution
+	SolutionErrOutput []byte
+	Interactor        sandbox.ExecResult
+}
+
+// Interactor runs a compiled testlib interactor as
+// `interactor <input> <result> <answer>` talking to the solution over stdio.
+type Interactor struct {
+	unit    *build.BuildUnit
+	sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs]
+	file    file.FileManager
+}
+
+func NewInteractor(
+	unit *build.BuildUnit,
+	sandbox sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs],
+	file file.FileManager,
+) *Interactor {
+	return &Interactor{unit: unit, sandbox: sandbox, file: file}
+}
+
+// Interact runs the solution against the interactor. The verdict is taken
+// from the interactor's exit code; the solution's own status is returned
+// as-is so the caller can decide on limit exceeded verdicts.
+func (i *Interactor) Interact(
+	solution *build.BuildUnit,
+	solutionReq sandbox.RunRequest,
+	interactorReq sandbox.RunRequest,
+	input, answer []byte,
+) (InteractResult, error) {
+	if i.unit == nil || i.unit.Dir == "" {
+		return InteractResult{}, fmt.Errorf("interactor build unit is not set up")
+	}
+
+	inputPath := i.file.MakeFilePath(i.unit.Dir, fmt.Sprintf("interactor-%d.in", interactorReq.Order)).String()
+	if err := i.file.CreateFile(inputPath, string(input)); err != nil {
+		return InteractResult{}, fmt.Errorf("writing interactor input file: %w", err)
+	}
+	answerPath := i.file.MakeFilePath(i.unit.Dir, fmt.Sprintf("interactor-%d.ans", interactorReq.Order)).String()
+	if err := i.file.CreateFile(answerPath, string(answer)); err != nil {
+		return InteractResult{}, fmt.Errorf("writing interactor answer file: %w", err)
+	}
+	resultPath := i.file.MakeFilePath(i.unit.Dir, fmt.Sprintf("interactor-%d.res", interactorReq.Order)).String()
+	interactorReq.ExtraArgs = []string{inputPath, resultPath, answerPath}
+
+	runResult, err := build.RunInteractive(i.sandbox, solution, solutionReq, i.unit, interactorReq)
+	if err != nil {
+		return InteractResult{
+			Solution:   runResult.Solution.ExecResult,
+			Interactor: runResult.Interactor.ExecResult,
+		}, fmt.Errorf("running interactor: %w", err)
+	}
+
+	return InteractResult{
+		Verdict:           VerdictFromExecResult(runResult.Interactor.ExecResult),
+		Message:           strings.TrimSpace(string(runResult.Interactor.ErrOutput)),
+		Solution:          runResult.Solution.ExecResult,
+		SolutionErrOutput: runResult.Solution.ErrOutput,
+		Interactor:        runResult.Interactor.ExecResult,
+	}, nil
+}
@@ -40,6 +40,21 @@ func (f *fileManager) RemoveDir(dir string) error {
 	return nil
 }
 
+// Clean removes everything left in the base directory, such as the run
+// directories of tasks canceled on shutdown.
+func (f *fileManager) Clean() error {
+	entries, err := os.ReadDir(f.baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to read dir: %s: %w", f.baseDir, err)
+	}
+	for _, entry := range entries {
+		if err := f.RemoveDir(entry.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *fileManager) CreateFile(path string, data string) error {
 	if err := os.WriteFile(path, []byte(data), constants.BASE_FILE_MODE); err != nil {
 		return fmt.Errorf("failed to create file: %s: %w", path, err)
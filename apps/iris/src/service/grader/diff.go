@@ -0,0 +1,231 @@
+package grader
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mismatch locates the first difference between the expected answer and the
+// output. Line and Column are 1-based and point into the output. Expected and
+// Actual hold the differing tokens (empty if one side ran out of tokens), and
+// the contexts hold a truncated window of the surrounding line.
+type Mismatch struct {
+	Line            int    `json:"line"`
+	Column          int    `json:"column"`
+	Expected        string `json:"expected"`
+	Actual          string `json:"actual"`
+	ExpectedContext string `json:"expectedContext"`
+	ActualContext   string `json:"actualContext"`
+}
+
+const (
+	maxTokenLength = 64
+	contextRadius  = 32
+	ellipsis       = "..."
+)
+
+type position struct {
+	line   int
+	offset int
+}
+
+type token struct {
+	text []byte
+	position
+}
+
+func splitLines(a []byte) [][]byte {
+	return bytes.Split(TrimWhitespaceBeforeNewline(a), []byte("\n"))
+}
+
+func lineAt(lines [][]byte, idx int) []byte {
+	if idx < 0 || idx >= len(lines) {
+		return nil
+	}
+	return lines[idx]
+}
+
+func endOf(lines [][]byte) position {
+	last := len(lines) - 1
+	return position{line: last, offset: len(lineAt(lines, last))}
+}
+
+func lineTokens(line []byte, lineIdx int) []token {
+	var tokens []token
+	start := -1
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRune(line[i:])
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				tokens = append(tokens, token{line[start:i], position{lineIdx, start}})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+		i += size
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{line[start:], position{lineIdx, start}})
+	}
+	return tokens
+}
+
+func tokenize(lines [][]byte) []token {
+	var tokens []token
+	for idx, line := range lines {
+		tokens = append(tokens, lineTokens(line, idx)...)
+	}
+	return tokens
+}
+
+func tokenAt(tokens []token, idx int, end position) ([]byte, position) {
+	if idx < len(tokens) {
+		return tokens[idx].text, tokens[idx].position
+	}
+	return nil, end
+}
+
+func newMismatch(expected, actual [][]byte, e, a position, expectedToken, actualToken []byte) *Mismatch {
+	actualLine := lineAt(actual, a.line)
+	return &Mismatch{
+		Line:            a.line + 1,
+		Column:          utf8.RuneCount(actualLine[:a.offset]) + 1,
+		Expected:        truncate(expectedToken),
+		Actual:          truncate(actualToken),
+		ExpectedContext: window(lineAt(expected, e.line), e.offset),
+		ActualContext:   window(actualLine, a.offset),
+	}
+}
+
+// diffLines compares line by line, then token by token within the first
+// differing line. If only the spacing between tokens differs, the mismatch
+// points at the first differing byte and carries no tokens.
+func diffLines(answer, output []byte, equal func(e, a []byte) bool) *Mismatch {
+	expected := splitLines(answer)
+	actual := splitLines(output)
+
+	for i := range max(len(expected), len(actual)) {
+		e, a := lineAt(expected, i), lineAt(actual, i)
+		if equal(e, a) {
+			continue
+		}
+
+		et, at := lineTokens(e, i), lineTokens(a, i)
+		for k := range max(len(et), len(at)) {
+			eText, ePos := tokenAt(et, k, position{i, len(e)})
+			aText, aPos := tokenAt(at, k, position{i, len(a)})
+			if k < len(et) && k < len(at) && equal(eText, aText) {
+				continue
+			}
+			if i >= len(actual) {
+				aPos = endOf(actual)
+			}
+			return newMismatch(expected, actual, ePos, aPos, eText, aText)
+		}
+
+		offset := commonPrefixLength(e, a)
+		return newMismatch(expected, actual, position{i, offset}, position{i, offset}, nil, nil)
+	}
+	return nil
+}
+
+// diffTokens compares the outputs as a stream of tokens, ignoring line
+// structure.
+func diffTokens(answer, output []byte, equal func(e, a []byte) bool) *Mismatch {
+	expected := splitLines(answer)
+	actual := splitLines(output)
+	et, at := tokenize(expected), tokenize(actual)
+
+	for k := range max(len(et), len(at)) {
+		eText, ePos := tokenAt(et, k, endOf(expected))
+		aText, aPos := tokenAt(at, k, endOf(actual))
+		if k < len(et) && k < len(at) && equal(eText, aText) {
+			continue
+		}
+		return newMismatch(expected, actual, ePos, aPos, eText, aText)
+	}
+	return nil
+}
+
+// diffUnorderedLines reports the first surplus output line together with the
+// first expected line that is missing from the output.
+func diffUnorderedLines(answer, output []byte) *Mismatch {
+	expected := splitLines(answer)
+	actual := splitLines(output)
+
+	remaining := make(map[string]int, len(expected))
+	for _, line := range expected {
+		remaining[string(line)]++
+	}
+	surplus := -1
+	for i, line := range actual {
+		if remaining[string(line)] > 0 {
+			remaining[string(line)]--
+		} else if surplus < 0 {
+			surplus = i
+		}
+	}
+	missing := -1
+	for i, line := range expected {
+		if remaining[string(line)] > 0 {
+			missing = i
+			break
+		}
+	}
+	if surplus < 0 && missing < 0 {
+		return nil
+	}
+
+	ePos, aPos := endOf(expected), endOf(actual)
+	if missing >= 0 {
+		ePos = position{line: missing}
+	}
+	if surplus >= 0 {
+		aPos = position{line: surplus}
+	}
+	return newMismatch(expected, actual, ePos, aPos, lineAt(expected, missing), lineAt(actual, surplus))
+}
+
+func commonPrefixLength(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+func truncate(text []byte) string {
+	if len(text) <= maxTokenLength {
+		return string(text)
+	}
+	end := maxTokenLength
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return string(text[:end]) + ellipsis
+}
+
+// window cuts line down to contextRadius bytes on each side of offset.
+func window(line []byte, offset int) string {
+	start := max(0, offset-contextRadius)
+	end := min(len(line), offset+contextRadius)
+	for start < end && !utf8.RuneStart(line[start]) {
+		start++
+	}
+	for end < len(line) && end > start && !utf8.RuneStart(line[end]) {
+		end--
+	}
+
+	text := string(line[start:end])
+	if start > 0 {
+		text = ellipsis + text
+	}
+	if end < len(line) {
+		text += ellipsis
+	}
+	return text
+}
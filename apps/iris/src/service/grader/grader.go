@@ -2,9 +2,63 @@ package grader
 
 import (
 	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"unicode"
 )
 
+type Grader interface {
+	Grade(answer []byte, output []byte) bool
+	// Diff returns the first mismatch, or nil if Grade would accept output.
+	Diff(answer []byte, output []byte) *Mismatch
+}
+
+type Strategy string
+
+const (
+	EXACT            Strategy = "exact"
+	TOKEN            Strategy = "token"
+	CASE_INSENSITIVE Strategy = "caseInsensitive"
+	FLOAT            Strategy = "float"
+	UNORDERED_LINES  Strategy = "unorderedLines"
+)
+
+const DefaultFloatTolerance = 1e-6
+
+func (s Strategy) IsValid() bool {
+	switch s {
+	case EXACT, TOKEN, CASE_INSENSITIVE, FLOAT, UNORDERED_LINES:
+		return true
+	}
+	return false
+}
+
+// New returns the grader for strategy. An empty strategy falls back to EXACT.
+// tolerance is only used by FLOAT, where zero means DefaultFloatTolerance.
+func New(strategy Strategy, tolerance float64) (Grader, error) {
+	switch strategy {
+	case "", EXACT:
+		return exactGrader{}, nil
+	case TOKEN:
+		return tokenGrader{}, nil
+	case CASE_INSENSITIVE:
+		return caseInsensitiveGrader{}, nil
+	case FLOAT:
+		if tolerance < 0 {
+			return nil, fmt.Errorf("float tolerance must not be negative")
+		}
+		if tolerance == 0 {
+			tolerance = DefaultFloatTolerance
+		}
+		return floatGrader{tolerance: tolerance}, nil
+	case UNORDERED_LINES:
+		return unorderedLinesGrader{}, nil
+	}
+	return nil, fmt.Errorf("unsupported grader: %s", strategy)
+}
+
 func Grade(answer []byte, output []byte) bool {
 	return bytes.Equal(TrimWhitespaceBeforeNewline(answer), TrimWhitespaceBeforeNewline(output))
 }
@@ -18,3 +72,82 @@ func TrimWhitespaceBeforeNewline(a []byte) []byte {
 	}
 	return bytes.Join(b, sep)
 }
+
+// exactGrader ignores trailing whitespace of each line and of the whole output.
+type exactGrader struct{}
+
+func (exactGrader) Grade(answer []byte, output []byte) bool {
+	return Grade(answer, output)
+}
+
+func (exactGrader) Diff(answer []byte, output []byte) *Mismatch {
+	return diffLines(answer, output, bytes.Equal)
+}
+
+// tokenGrader compares whitespace-separated tokens, ignoring line structure.
+type tokenGrader struct{}
+
+func (tokenGrader) Grade(answer []byte, output []byte) bool {
+	return slices.EqualFunc(bytes.Fields(answer), bytes.Fields(output), bytes.Equal)
+}
+
+func (tokenGrader) Diff(answer []byte, output []byte) *Mismatch {
+	return diffTokens(answer, output, bytes.Equal)
+}
+
+// caseInsensitiveGrader is exactGrader with Unicode case folding.
+type caseInsensitiveGrader struct{}
+
+func (caseInsensitiveGrader) Grade(answer []byte, output []byte) bool {
+	return bytes.EqualFold(TrimWhitespaceBeforeNewline(answer), TrimWhitespaceBeforeNewline(output))
+}
+
+func (caseInsensitiveGrader) Diff(answer []byte, output []byte) *Mismatch {
+	return diffLines(answer, output, bytes.EqualFold)
+}
+
+// floatGrader compares tokens, accepting numbers within an absolute or
+// relative error of tolerance. Non-numeric tokens must match exactly.
+type floatGrader struct {
+	tolerance float64
+}
+
+func (g floatGrader) Grade(answer []byte, output []byte) bool {
+	return slices.EqualFunc(bytes.Fields(answer), bytes.Fields(output), g.equalToken)
+}
+
+func (g floatGrader) Diff(answer []byte, output []byte) *Mismatch {
+	return diffTokens(answer, output, g.equalToken)
+}
+
+func (g floatGrader) equalToken(expected []byte, actual []byte) bool {
+	if bytes.Equal(expected, actual) {
+		return true
+	}
+	e, err := strconv.ParseFloat(string(expected), 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(string(actual), 64)
+	if err != nil || math.IsNaN(a) || math.IsInf(a, 0) {
+		return false
+	}
+	diff := math.Abs(e - a)
+	return diff <= g.tolerance || diff <= g.tolerance*math.Abs(e)
+}
+
+// unorderedLinesGrader accepts any permutation of the expected lines.
+type unorderedLinesGrader struct{}
+
+func (unorderedLinesGrader) Grade(answer []byte, output []byte) bool {
+	sep := []byte("\n")
+	expected := bytes.Split(TrimWhitespaceBeforeNewline(answer), sep)
+	actual := bytes.Split(TrimWhitespaceBeforeNewline(output), sep)
+	slices.SortFunc(expected, bytes.Compare)
+	slices.SortFunc(actual, bytes.Compare)
+	return slices.EqualFunc(expected, actual, bytes.Equal)
+}
+
+func (unorderedLinesGrader) Diff(answer []byte, output []byte) *Mismatch {
+	return diffUnorderedLines(answer, output)
+}
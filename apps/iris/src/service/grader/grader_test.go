@@ -0,0 +1,213 @@
+package grader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraders(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  Strategy
+		tolerance float64
+		answer    string
+		output    string
+		want      bool
+	}{
+		{"exact ignores trailing whitespace", EXACT, 0, "1 2\n3\n", "1 2  \n3", true},
+		{"exact keeps inner whitespace", EXACT, 0, "1 2", "1  2", false},
+		{"empty strategy is exact", "", 0, "a\n", "a", true},
+		{"token ignores line structure", TOKEN, 0, "1 2\n3", "1\n2   3\n\n", true},
+		{"token detects different tokens", TOKEN, 0, "1 2 3", "1 2", false},
+		{"case insensitive", CASE_INSENSITIVE, 0, "YES\nNo", "yes\nno", true},
+		{"case insensitive keeps lines", CASE_INSENSITIVE, 0, "YES NO", "yes\nno", false},
+		{"float within absolute error", FLOAT, 0, "0.1234567", "0.1234571", true},
+		{"float within relative error", FLOAT, 0, "1000000.0", "1000000.5", true},
+		{"float out of error", FLOAT, 0, "0.5", "0.5001", false},
+		{"float custom tolerance", FLOAT, 1e-3, "0.5", "0.5001", true},
+		{"float compares words exactly", FLOAT, 0, "YES 0.5", "NO 0.5", false},
+		{"float rejects nan", FLOAT, 0, "0.5", "nan", false},
+		{"unordered lines", UNORDERED_LINES, 0, "a\nb\nc\n", "c\na\nb", true},
+		{"unordered lines detects missing line", UNORDERED_LINES, 0, "a\nb\nb", "a\na\nb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.strategy, tt.tolerance)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, g.Grade([]byte(tt.answer), []byte(tt.output)))
+			assert.Equal(t, tt.want, g.Diff([]byte(tt.answer), []byte(tt.output)) == nil)
+
+			accepted, err := GradeReader(g, strings.NewReader(tt.answer), strings.NewReader(tt.output))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, accepted)
+		})
+	}
+}
+
+func TestGradeReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		answer   string
+		output   string
+	}{
+		{"crlf line endings", EXACT, "1\n2\n", "1\r\n2\r\n"},
+		{"trailing blank lines", EXACT, "1\n2", "1\n2\n\n  \n"},
+		{"blank lines in between", EXACT, "1\n\n2", "1\n \n2"},
+		{"leading whitespace", EXACT, "  1\n 2", "  1\n2"},
+		{"missing line", EXACT, "1\n2", "1"},
+		{"surplus output", EXACT, "1", "1\n2"},
+		{"empty output", EXACT, "1", ""},
+		{"unicode whitespace", EXACT, "가\u00a0\n나", "가\n나"},
+		{"invalid utf-8", EXACT, "a\xffb", "a\xfeb"},
+		{"case folding", CASE_INSENSITIVE, "ΣΑΣ Yes\n", "σας yes  "},
+		{"case folding keeps letters", CASE_INSENSITIVE, "yes", "yet"},
+		{"tokens across lines", TOKEN, "1 2\n3", "1\n\t2 3 "},
+		{"float tokens", FLOAT, "0.5 1e3", "0.5000001 1000"},
+		{"unordered lines", UNORDERED_LINES, "a\nb", "b\na\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.strategy, 0)
+			require.NoError(t, err)
+
+			accepted, err := GradeReader(g, strings.NewReader(tt.answer), strings.NewReader(tt.output))
+			require.NoError(t, err)
+			assert.Equal(t, g.Grade([]byte(tt.answer), []byte(tt.output)), accepted)
+		})
+	}
+
+	t.Run("compares outputs larger than the read buffers", func(t *testing.T) {
+		g, err := New(EXACT, 0)
+		require.NoError(t, err)
+		answer := strings.Repeat("12345 67890\n", 100000)
+
+		accepted, err := GradeReader(g, strings.NewReader(answer), strings.NewReader(answer+"\n"))
+		require.NoError(t, err)
+		assert.True(t, accepted)
+
+		accepted, err = GradeReader(g, strings.NewReader(answer), strings.NewReader(answer+"0"))
+		require.NoError(t, err)
+		assert.False(t, accepted)
+	})
+
+	t.Run("rejects output tokens longer than the limit", func(t *testing.T) {
+		g, err := New(TOKEN, 0)
+		require.NoError(t, err)
+
+		accepted, err := GradeReader(g, strings.NewReader("1"), strings.NewReader(strings.Repeat("1", maxStreamTokenSize+1)))
+		require.NoError(t, err)
+		assert.False(t, accepted)
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("rejects unknown strategy", func(t *testing.T) {
+		_, err := New("fuzzy", 0)
+		assert.EqualError(t, err, "unsupported grader: fuzzy")
+	})
+
+	t.Run("rejects negative tolerance", func(t *testing.T) {
+		_, err := New(FLOAT, -1)
+		assert.EqualError(t, err, "float tolerance must not be negative")
+	})
+}
+
+func TestDiff(t *testing.T) {
+	long := strings.Repeat("x", 100)
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		answer   string
+		output   string
+		want     Mismatch
+	}{
+		{
+			name:     "different token",
+			strategy: EXACT,
+			answer:   "1 2\n3 42\n",
+			output:   "1 2\n3 41\n",
+			want:     Mismatch{Line: 2, Column: 3, Expected: "42", Actual: "41", ExpectedContext: "3 42", ActualContext: "3 41"},
+		},
+		{
+			name:     "missing token",
+			strategy: EXACT,
+			answer:   "1 2 3",
+			output:   "1 2",
+			want:     Mismatch{Line: 1, Column: 4, Expected: "3", ExpectedContext: "1 2 3", ActualContext: "1 2"},
+		},
+		{
+			name:     "missing line",
+			strategy: EXACT,
+			answer:   "1\n2",
+			output:   "1",
+			want:     Mismatch{Line: 1, Column: 2, Expected: "2", ExpectedContext: "2", ActualContext: "1"},
+		},
+		{
+			name:     "spacing only",
+			strategy: EXACT,
+			answer:   "1 2",
+			output:   "1  2",
+			want:     Mismatch{Line: 1, Column: 3, ExpectedContext: "1 2", ActualContext: "1  2"},
+		},
+		{
+			name:     "columns count runes",
+			strategy: EXACT,
+			answer:   "가나 다",
+			output:   "가나 라",
+			want:     Mismatch{Line: 1, Column: 4, Expected: "다", Actual: "라", ExpectedContext: "가나 다", ActualContext: "가나 라"},
+		},
+		{
+			name:     "truncates tokens and context",
+			strategy: EXACT,
+			answer:   long + "a",
+			output:   long + "b",
+			want: Mismatch{
+				Line:            1,
+				Column:          1,
+				Expected:        strings.Repeat("x", 64) + "...",
+				Actual:          strings.Repeat("x", 64) + "...",
+				ExpectedContext: strings.Repeat("x", 32) + "...",
+				ActualContext:   strings.Repeat("x", 32) + "...",
+			},
+		},
+		{
+			name:     "token stream points into output lines",
+			strategy: TOKEN,
+			answer:   "1 2 3",
+			output:   "1\n2\n4",
+			want:     Mismatch{Line: 3, Column: 1, Expected: "3", Actual: "4", ExpectedContext: "1 2 3", ActualContext: "4"},
+		},
+		{
+			name:     "float reports token out of tolerance",
+			strategy: FLOAT,
+			answer:   "0.5 0.25",
+			output:   "0.5000001 0.3",
+			want:     Mismatch{Line: 1, Column: 11, Expected: "0.25", Actual: "0.3", ExpectedContext: "0.5 0.25", ActualContext: "0.5000001 0.3"},
+		},
+		{
+			name:     "unordered lines reports surplus and missing line",
+			strategy: UNORDERED_LINES,
+			answer:   "a\nb\nc",
+			output:   "c\nd\na",
+			want:     Mismatch{Line: 2, Column: 1, Expected: "b", Actual: "d", ExpectedContext: "b", ActualContext: "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.strategy, 0)
+			require.NoError(t, err)
+
+			got := g.Diff([]byte(tt.answer), []byte(tt.output))
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package grader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxStreamTokenSize bounds a single token read by the token based graders.
+// An output token longer than that cannot match any answer token.
+const maxStreamTokenSize = 64 * 1024 * 1024
+
+// GradeReader is Grade for outputs too large to be held in memory. Line and
+// token based graders compare both sides as they are read; graders that need
+// the whole output (e.g. unorderedLines) read it fully.
+func GradeReader(g Grader, answer io.Reader, output io.Reader) (bool, error) {
+	switch g := g.(type) {
+	case exactGrader:
+		return equalStreams(newTrimReader(answer), newTrimReader(output))
+	case caseInsensitiveGrader:
+		return equalFoldStreams(newTrimReader(answer), newTrimReader(output))
+	case tokenGrader:
+		return equalTokens(answer, output, bytes.Equal)
+	case floatGrader:
+		return equalTokens(answer, output, g.equalToken)
+	}
+
+	expected, err := io.ReadAll(answer)
+	if err != nil {
+		return false, err
+	}
+	actual, err := io.ReadAll(output)
+	if err != nil {
+		return false, err
+	}
+	return g.Grade(expected, actual), nil
+}
+
+func equalStreams(a, b io.Reader) (bool, error) {
+	ra, rb := bufio.NewReader(a), bufio.NewReader(b)
+	for {
+		x, errA := ra.ReadByte()
+		y, errB := rb.ReadByte()
+		if errA != nil || errB != nil {
+			return endOfStreams(errA, errB)
+		}
+		if x != y {
+			return false, nil
+		}
+	}
+}
+
+// equalFoldStreams matches bytes.EqualFold rune by rune.
+func equalFoldStreams(a, b io.Reader) (bool, error) {
+	ra, rb := bufio.NewReader(a), bufio.NewReader(b)
+	for {
+		x, _, errA := ra.ReadRune()
+		y, _, errB := rb.ReadRune()
+		if errA != nil || errB != nil {
+			return endOfStreams(errA, errB)
+		}
+		if !equalFoldRune(x, y) {
+			return false, nil
+		}
+	}
+}
+
+func equalFoldRune(x, y rune) bool {
+	if x == y {
+		return true
+	}
+	for r := unicode.SimpleFold(x); r != x; r = unicode.SimpleFold(r) {
+		if r == y {
+			return true
+		}
+	}
+	return false
+}
+
+// endOfStreams decides the comparison once either side stopped: the streams
+// are equal only if both ended together.
+func endOfStreams(errA, errB error) (bool, error) {
+	if errA != nil && errA != io.EOF {
+		return false, errA
+	}
+	if errB != nil && errB != io.EOF {
+		return false, errB
+	}
+	return errA == io.EOF && errB == io.EOF, nil
+}
+
+func equalTokens(answer io.Reader, output io.Reader, equal func(e, a []byte) bool) (bool, error) {
+	expected, actual := newTokenScanner(answer), newTokenScanner(output)
+	for {
+		more := expected.Scan()
+		if err := expected.Err(); err != nil {
+			return false, err
+		}
+		if actual.Scan() != more || actual.Err() != nil {
+			if errors.Is(actual.Err(), bufio.ErrTooLong) {
+				return false, nil
+			}
+			return false, actual.Err()
+		}
+		if !more {
+			return true, nil
+		}
+		if !equal(expected.Bytes(), actual.Bytes()) {
+			return false, nil
+		}
+	}
+}
+
+func newTokenScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamTokenSize)
+	scanner.Split(bufio.ScanWords)
+	return scanner
+}
+
+// trimReader yields what TrimWhitespaceBeforeNewline would return for the
+// whole input. A run of whitespace is held back until the next non-space
+// rune shows whether it ends a line (only its newlines are kept) or the input
+// (it is dropped).
+type trimReader struct {
+	src      *bufio.Reader
+	newlines int
+	// tail is the whitespace after the last newline of the pending run
+	tail    []byte
+	out     []byte
+	written int
+}
+
+func newTrimReader(r io.Reader) *trimReader {
+	return &trimReader{src: bufio.NewReader(r)}
+}
+
+func (t *trimReader) Read(p []byte) (int, error) {
+	for len(t.out)-t.written < len(p) {
+		r, size, err := t.src.ReadRune()
+		if err != nil {
+			if t.written < len(t.out) {
+				break
+			}
+			return 0, err
+		}
+		switch {
+		case r == '\n':
+			t.newlines++
+			t.tail = t.tail[:0]
+		case unicode.IsSpace(r):
+			t.tail = utf8.AppendRune(t.tail, r)
+		default:
+			for range t.newlines {
+				t.out = append(t.out, '\n')
+			}
+			t.out = append(t.out, t.tail...)
+			t.newlines, t.tail = 0, t.tail[:0]
+			if r == utf8.RuneError && size == 1 {
+				// invalid UTF-8 is passed through as is
+				t.src.UnreadRune()
+				b, _ := t.src.ReadByte()
+				t.out = append(t.out, b)
+			} else {
+				t.out = utf8.AppendRune(t.out, r)
+			}
+		}
+	}
+	n := copy(p, t.out[t.written:])
+	t.written += n
+	if t.written == len(t.out) {
+		t.out, t.written = t.out[:0], 0
+	}
+	return n, nil
+}
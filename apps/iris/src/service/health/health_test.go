@@ -0,0 +1,20 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check())
+
+	SetDown("rabbitmq", errors.New("connection closed"))
+	SetDown("database", errors.New("timeout"))
+	assert.EqualError(t, Check(), "unhealthy: database: timeout; rabbitmq: connection closed")
+
+	SetUp("database")
+	SetUp("rabbitmq")
+	assert.NoError(t, Check())
+}
@@ -0,0 +1,278 @@
+// Package fake provides an in-process sandbox whose compile and run results
+// are scripted, so that handlers and the router can be tested without
+// libjudger or isolate.
+package fake
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+)
+
+// Result scripts the outcome of one compile or run.
+type Result struct {
+	Status sandbox.StatusCode
+	// Output is what the process printed to stdout. Stderr becomes the
+	// compile error message of a compile.
+	Output   []byte
+	Stderr   []byte
+	CpuTime  int
+	RealTime int
+	Memory   int
+	ExitCode int
+	Signal   int
+	// Latency delays the result, e.g. to make runs finish out of order
+	Latency time.Duration
+	// Panic makes the call panic with this value when set
+	Panic any
+	// Err fails the sandbox itself rather than the process
+	Err error
+}
+
+type (
+	CompileFunc  func(req sandbox.CompileRequest) Result
+	RunFunc      func(req sandbox.RunRequest, input []byte) Result
+	InteractFunc func(solution sandbox.RunRequest, interactor sandbox.RunRequest) (Result, Result)
+)
+
+// Run is a call of Sandbox.Run as it was received.
+type Run struct {
+	Request sandbox.RunRequest
+	Input   []byte
+}
+
+// Sandbox implements sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs].
+// Sources are saved and outputs written through the file manager like the
+// real sandbox does, so the run and build directories must exist. By default
+// every compile succeeds and every run echoes its input.
+type Sandbox struct {
+	file file.FileManager
+
+	mu       sync.Mutex
+	compile  CompileFunc
+	run      RunFunc
+	interact InteractFunc
+	compiles []sandbox.CompileRequest
+	runs     []Run
+}
+
+func New(fileManager file.FileManager) *Sandbox {
+	return &Sandbox{
+		file:    fileManager,
+		compile: func(sandbox.CompileRequest) Result { return Result{} },
+		run:     Echo,
+		interact: func(sandbox.RunRequest, sandbox.RunRequest) (Result, Result) {
+			return Result{}, Result{}
+		},
+	}
+}
+
+// Echo is a RunFunc that prints the input.
+func Echo(_ sandbox.RunRequest, input []byte) Result {
+	return Result{Output: input}
+}
+
+// Respond returns a RunFunc giving the results in the order of the calls.
+// The last result is repeated once they are used up.
+func Respond(results ...Result) RunFunc {
+	var mu sync.Mutex
+	calls := 0
+	return func(sandbox.RunRequest, []byte) Result {
+		mu.Lock()
+		defer mu.Unlock()
+		result := results[min(calls, len(results)-1)]
+		calls++
+		return result
+	}
+}
+
+// ByOrder returns a RunFunc giving the result of the run's order (i.e. the
+// index of the testcase), and falling back to fallback for other orders.
+func ByOrder(results map[int]Result, fallback RunFunc) RunFunc {
+	return func(req sandbox.RunRequest, input []byte) Result {
+		if result, ok := results[req.Order]; ok {
+			return result
+		}
+		return fallback(req, input)
+	}
+}
+
+func (s *Sandbox) OnCompile(f CompileFunc) *Sandbox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compile = f
+	return s
+}
+
+func (s *Sandbox) OnRun(f RunFunc) *Sandbox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.run = f
+	return s
+}
+
+func (s *Sandbox) OnInteract(f InteractFunc) *Sandbox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interact = f
+	return s
+}
+
+// Compiles returns the compile requests received so far.
+func (s *Sandbox) Compiles() []sandbox.CompileRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sandbox.CompileRequest(nil), s.compiles...)
+}
+
+// Runs returns the runs received so far, in the order they started.
+func (s *Sandbox) Runs() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Run(nil), s.runs...)
+}
+
+func (s *Sandbox) Compile(req sandbox.CompileRequest) (sandbox.CompileResult, error) {
+	s.mu.Lock()
+	s.compiles = append(s.compiles, req)
+	compile := s.compile
+	s.mu.Unlock()
+
+	result := play(compile(req))
+	if result.Err != nil {
+		return sandbox.CompileResult{}, result.Err
+	}
+	return sandbox.CompileResult{ErrOutput: string(result.Stderr), ExecResult: result.execResult()}, nil
+}
+
+func (s *Sandbox) Run(req sandbox.RunRequest, input []byte) (sandbox.RunResult, error) {
+	if req.InputPath != "" {
+		data, err := os.ReadFile(req.InputPath)
+		if err != nil {
+			return sandbox.RunResult{}, fmt.Errorf("reading input file: %w", err)
+		}
+		input = data
+	}
+
+	s.mu.Lock()
+	s.runs = append(s.runs, Run{Request: req, Input: input})
+	run := s.run
+	s.mu.Unlock()
+
+	result := play(run(req, input))
+	runResult := sandbox.RunResult{Order: req.Order, ErrOutput: result.Stderr, ExecResult: result.execResult()}
+	if result.Err != nil {
+		return runResult, result.Err
+	}
+
+	runResult.OutputPath = s.artifactPath(req, ".out")
+	if err := s.file.CreateFile(runResult.OutputPath, string(result.Output)); err != nil {
+		return runResult, fmt.Errorf("writing output file: %w", err)
+	}
+	if !req.OutputOnDisk {
+		runResult.Output = result.Output
+		if runResult.Output == nil {
+			runResult.Output = []byte{}
+		}
+	}
+	return runResult, nil
+}
+
+func (s *Sandbox) RunInteractive(solution sandbox.RunRequest, interactor sandbox.RunRequest) (sandbox.InteractiveRunResult, error) {
+	s.mu.Lock()
+	interact := s.interact
+	s.mu.Unlock()
+
+	solutionResult, interactorResult := interact(solution, interactor)
+	solutionResult, interactorResult = play(solutionResult), play(interactorResult)
+	result := sandbox.InteractiveRunResult{
+		Solution:   sandbox.RunResult{Order: solution.Order, ErrOutput: solutionResult.Stderr, ExecResult: solutionResult.execResult()},
+		Interactor: sandbox.RunResult{Order: interactor.Order, ErrOutput: interactorResult.Stderr, ExecResult: interactorResult.execResult()},
+	}
+	if solutionResult.Err != nil {
+		return result, fmt.Errorf("solution execution failed: %w", solutionResult.Err)
+	}
+	if interactorResult.Err != nil {
+		return result, fmt.Errorf("interactor execution failed: %w", interactorResult.Err)
+	}
+	return result, nil
+}
+
+func (s *Sandbox) GetConfig(language sandbox.Language) (judger.JudgerConfig, error) {
+	if !language.IsValid() {
+		return judger.JudgerConfig{}, fmt.Errorf("unsupported language: %s", language)
+	}
+	return judger.JudgerConfig{Language: language, SrcName: "main.src", ExeName: "main"}, nil
+}
+
+func (s *Sandbox) MakeSrcPath(dir string, language sandbox.Language) (string, error) {
+	config, err := s.GetConfig(language)
+	if err != nil {
+		return "", err
+	}
+	return s.file.MakeFilePath(dir, config.SrcName).String(), nil
+}
+
+func (s *Sandbox) ToCompileExecArgs(dir string, language sandbox.Language) (judger.ExecArgs, error) {
+	config, err := s.GetConfig(language)
+	if err != nil {
+		return judger.ExecArgs{}, err
+	}
+	return judger.ExecArgs{ExePath: s.file.MakeFilePath(dir, config.ExeName).String()}, nil
+}
+
+func (s *Sandbox) ToRunExecArgs(dir string, language sandbox.Language, order int, limit sandbox.Limit, fileIo bool, extraArgs []string) (judger.ExecArgs, error) {
+	config, err := s.GetConfig(language)
+	if err != nil {
+		return judger.ExecArgs{}, err
+	}
+	maxOutputSize := limit.Output
+	if maxOutputSize <= 0 {
+		maxOutputSize = sandbox.DefaultOutputLimit
+	}
+	return judger.ExecArgs{
+		ExePath:       s.file.MakeFilePath(dir, config.ExeName).String(),
+		MaxCpuTime:    limit.CpuTime,
+		MaxRealTime:   limit.RealTime,
+		MaxMemory:     limit.Memory,
+		MaxOutputSize: maxOutputSize,
+		OutputPath:    s.file.MakeFilePath(dir, strconv.Itoa(order)+".out").String(),
+		ErrorPath:     s.file.MakeFilePath(dir, strconv.Itoa(order)+".error").String(),
+		Args:          extraArgs,
+		FileIo:        fileIo,
+	}, nil
+}
+
+func (s *Sandbox) artifactPath(req sandbox.RunRequest, ext string) string {
+	dir := req.Dir
+	if req.OutputDir != "" {
+		dir = req.OutputDir
+	}
+	return s.file.MakeFilePath(dir, strconv.Itoa(req.Order)+ext).String()
+}
+
+// play waits for the latency of result and panics if it says so.
+func play(result Result) Result {
+	time.Sleep(result.Latency)
+	if result.Panic != nil {
+		panic(result.Panic)
+	}
+	return result
+}
+
+func (r Result) execResult() sandbox.ExecResult {
+	return sandbox.ExecResult{
+		CpuTime:    r.CpuTime,
+		RealTime:   r.RealTime,
+		Memory:     r.Memory,
+		Signal:     r.Signal,
+		ExitCode:   r.ExitCode,
+		StatusCode: r.Status,
+	}
+}
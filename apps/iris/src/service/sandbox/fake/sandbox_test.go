@@ -0,0 +1,111 @@
+package fake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs] = (*Sandbox)(nil)
+
+func newSandbox(t *testing.T) (*Sandbox, string) {
+	baseDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(baseDir, "unit"), 0o755))
+	return New(file.NewFileManager(baseDir)), baseDir
+}
+
+func TestRun(t *testing.T) {
+	t.Run("echoes the input by default", func(t *testing.T) {
+		s, baseDir := newSandbox(t)
+
+		result, err := s.Run(sandbox.RunRequest{Order: 2, Dir: "unit"}, []byte("hello"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), result.Output)
+		assert.Equal(t, 2, result.Order)
+		assert.Equal(t, filepath.Join(baseDir, "unit", "2.out"), result.OutputPath)
+		output, err := os.ReadFile(result.OutputPath)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(output))
+	})
+
+	t.Run("reads the input file and leaves the output on disk", func(t *testing.T) {
+		s, baseDir := newSandbox(t)
+		inputPath := filepath.Join(baseDir, "1.in")
+		require.NoError(t, os.WriteFile(inputPath, []byte("from disk"), 0o644))
+
+		result, err := s.Run(sandbox.RunRequest{Dir: "unit", InputPath: inputPath, OutputOnDisk: true}, nil)
+
+		require.NoError(t, err)
+		assert.Nil(t, result.Output)
+		output, err := os.ReadFile(result.OutputPath)
+		require.NoError(t, err)
+		assert.Equal(t, "from disk", string(output))
+		assert.Equal(t, []byte("from disk"), s.Runs()[0].Input)
+	})
+
+	t.Run("scripts results by order", func(t *testing.T) {
+		s, _ := newSandbox(t)
+		s.OnRun(ByOrder(map[int]Result{1: {Status: sandbox.RUNTIME_ERROR, Signal: 11, Stderr: []byte("boom"), CpuTime: 5}}, Echo))
+
+		first, err := s.Run(sandbox.RunRequest{Order: 0, Dir: "unit"}, []byte("a"))
+		require.NoError(t, err)
+		second, err := s.Run(sandbox.RunRequest{Order: 1, Dir: "unit"}, []byte("b"))
+		require.NoError(t, err)
+
+		assert.Equal(t, sandbox.RUN_SUCCESS, first.ExecResult.StatusCode)
+		assert.Equal(t, sandbox.ExecResult{StatusCode: sandbox.RUNTIME_ERROR, Signal: 11, CpuTime: 5}, second.ExecResult)
+		assert.Equal(t, []byte("boom"), second.ErrOutput)
+	})
+
+	t.Run("repeats the last response", func(t *testing.T) {
+		s, _ := newSandbox(t)
+		s.OnRun(Respond(Result{Output: []byte("1")}, Result{Output: []byte("2")}))
+
+		var outputs []string
+		for range 3 {
+			result, err := s.Run(sandbox.RunRequest{Dir: "unit"}, nil)
+			require.NoError(t, err)
+			outputs = append(outputs, string(result.Output))
+		}
+		assert.Equal(t, []string{"1", "2", "2"}, outputs)
+	})
+
+	t.Run("fails or panics as scripted", func(t *testing.T) {
+		s, _ := newSandbox(t)
+		s.OnRun(Respond(Result{Err: errors.New("judger crashed")}))
+		_, err := s.Run(sandbox.RunRequest{Dir: "unit"}, nil)
+		assert.EqualError(t, err, "judger crashed")
+
+		s.OnRun(Respond(Result{Panic: "out of boxes"}))
+		assert.PanicsWithValue(t, "out of boxes", func() { s.Run(sandbox.RunRequest{Dir: "unit"}, nil) })
+	})
+}
+
+func TestCompile(t *testing.T) {
+	s, _ := newSandbox(t)
+	s.OnCompile(func(req sandbox.CompileRequest) Result {
+		if req.Language == sandbox.PYTHON {
+			return Result{Status: sandbox.RUNTIME_ERROR, ExitCode: 1, Stderr: []byte("SyntaxError")}
+		}
+		return Result{}
+	})
+
+	result, err := s.Compile(sandbox.CompileRequest{Dir: "unit", Language: sandbox.C})
+	require.NoError(t, err)
+	assert.Equal(t, sandbox.RUN_SUCCESS, result.ExecResult.StatusCode)
+
+	result, err = s.Compile(sandbox.CompileRequest{Dir: "unit", Language: sandbox.PYTHON})
+	require.NoError(t, err)
+	assert.Equal(t, sandbox.RUNTIME_ERROR, result.ExecResult.StatusCode)
+	assert.Equal(t, "SyntaxError", result.ErrOutput)
+
+	assert.Len(t, s.Compiles(), 2)
+}
@@ -0,0 +1,13 @@
+package sandbox
+
+type Interactor interface {
+	RunInteractive(solution RunRequest, interactor RunRequest) (InteractiveRunResult, error)
+}
+
+// InteractiveRunResult holds the results of a solution and an interactor that
+// ran concurrently with their stdin/stdout connected to each other.
+// Output is always empty since both outputs are consumed by the other side.
+type InteractiveRunResult struct {
+	Solution   RunResult
+	Interactor RunResult
+}
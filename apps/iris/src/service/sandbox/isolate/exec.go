@@ -0,0 +1,312 @@
+package isolate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+)
+
+const (
+	PathEnv         = "ISOLATE_PATH"
+	BoxCountEnv     = "ISOLATE_BOX_COUNT"
+	CgroupEnv       = "ISOLATE_CGROUP"
+	MaxProcessesEnv = "ISOLATE_MAX_PROCESSES"
+
+	DefaultPath         = "/usr/local/bin/isolate"
+	DefaultBoxCount     = 64
+	DefaultMaxProcesses = 64
+
+	// isolate exits with 1 if the program failed and with 2 if isolate did
+	exitProgramFailed = 1
+)
+
+// defaultDirs are mounted into every box by isolate itself.
+var defaultDirs = []string{"/bin", "/dev", "/lib", "/lib64", "/usr", "/proc"}
+
+type Config struct {
+	Path string
+	// BoxCount is the number of boxes (ids 0 to BoxCount-1) used at once
+	BoxCount int
+	// Cgroup limits and measures memory with cgroups (isolate --cg) instead
+	// of the address space limit and max RSS
+	Cgroup bool
+	// MaxProcesses bounds the processes and threads of a run
+	MaxProcesses int
+}
+
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Path:         DefaultPath,
+		BoxCount:     DefaultBoxCount,
+		Cgroup:       true,
+		MaxProcesses: DefaultMaxProcesses,
+	}
+	if path := os.Getenv(PathEnv); path != "" {
+		config.Path = path
+	}
+	for env, target := range map[string]*int{
+		BoxCountEnv:     &config.BoxCount,
+		MaxProcessesEnv: &config.MaxProcesses,
+	} {
+		parsed, err := utils.GetenvPositiveInt(env, *target)
+		if err != nil {
+			return Config{}, err
+		}
+		*target = parsed
+	}
+	if value := os.Getenv(CgroupEnv); value != "" {
+		cgroup, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %q", CgroupEnv, value)
+		}
+		config.Cgroup = cgroup
+	}
+	return config, nil
+}
+
+// isolateExec runs processes in isolate boxes instead of libjudger. Each
+// execution takes a free box, initializes it and cleans it up afterwards, so
+// that nothing is left over between runs. The files of a run are opened by
+// iris and passed to the box as stdin/stdout/stderr; only the directories the
+// process refers to (e.g. the build directory) are mounted into the box.
+type isolateExec struct {
+	config Config
+	boxes  chan int
+	logger logger.Logger
+}
+
+func NewIsolateExec(config Config, logger logger.Logger) *isolateExec {
+	boxes := make(chan int, config.BoxCount)
+	for id := range config.BoxCount {
+		boxes <- id
+	}
+	return &isolateExec{config: config, boxes: boxes, logger: logger}
+}
+
+func (i *isolateExec) Exec(args judger.ExecArgs, input []byte) (sandbox.ExecResult, error) {
+	var stdin io.Reader = bytes.NewReader(input)
+	if args.InputPath != "" {
+		file, err := os.Open(args.InputPath)
+		if err != nil {
+			return sandbox.ExecResult{}, fmt.Errorf("opening input file: %w", err)
+		}
+		defer file.Close()
+		stdin = file
+	}
+	stdout, err := os.Create(args.OutputPath)
+	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("creating output file: %w", err)
+	}
+	defer stdout.Close()
+
+	// 컴파일은 stdout과 stderr를 한 파일에 기록하므로, 두 번 열면 서로 덮어씀
+	var stderr io.Writer = stdout
+	if args.ErrorPath != args.OutputPath {
+		file, closeFile, err := openStderr(args.ErrorPath)
+		if err != nil {
+			return sandbox.ExecResult{}, err
+		}
+		defer closeFile()
+		stderr = file
+	}
+	return i.run(args, stdin, stdout, stderr, nil)
+}
+
+// ExecPiped takes ownership of both pipe ends like the libjudger version and
+// closes them once the box is running.
+func (i *isolateExec) ExecPiped(args judger.ExecArgs, stdin *os.File, stdout *os.File) (sandbox.ExecResult, error) {
+	var once sync.Once
+	closePipes := func() {
+		once.Do(func() {
+			stdin.Close()
+			stdout.Close()
+		})
+	}
+	// 박스 초기화에 실패해도 상대 프로세스가 EOF를 받도록 닫음
+	defer closePipes()
+	stderr, closeStderr, err := openStderr(args.ErrorPath)
+	if err != nil {
+		return sandbox.ExecResult{}, err
+	}
+	defer closeStderr()
+	return i.run(args, stdin, stdout, stderr, closePipes)
+}
+
+// openStderr creates the error file at path, or discards stderr if path is
+// empty. closeFile must be called once the process is done.
+func openStderr(path string) (stderr io.Writer, closeFile func(), err error) {
+	if path == "" {
+		return io.Discard, func() {}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating error file: %w", err)
+	}
+	return file, func() { file.Close() }, nil
+}
+
+func (i *isolateExec) run(args judger.ExecArgs, stdin io.Reader, stdout io.Writer, stderr io.Writer, started func()) (sandbox.ExecResult, error) {
+	box := <-i.boxes
+	defer func() { i.boxes <- box }()
+
+	if err := i.box(box, "--init"); err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("initializing box %d: %w", box, err)
+	}
+	defer func() {
+		if err := i.box(box, "--cleanup"); err != nil {
+			i.logger.Log(logger.WARN, fmt.Sprintf("failed to clean up box %d: %s", box, err))
+		}
+	}()
+
+	meta, err := os.CreateTemp("", "isolate-meta-*")
+	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("creating meta file: %w", err)
+	}
+	meta.Close()
+	defer os.Remove(meta.Name())
+
+	cmd := exec.Command(i.config.Path, i.runArgs(box, args, meta.Name())...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = judger.StartPinned(cmd, args.CpuSet)
+	if started != nil {
+		started()
+	}
+	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("sandbox execution failed: %w", err)
+	}
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == exitProgramFailed) {
+		return sandbox.ExecResult{}, fmt.Errorf("sandbox execution failed: %w", err)
+	}
+
+	data, err := os.ReadFile(meta.Name())
+	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("reading meta file: %w", err)
+	}
+	i.logger.Log(logger.DEBUG, fmt.Sprintf("isolate meta: %s", strings.ReplaceAll(string(data), "\n", " ")))
+	return ParseMeta(data, args)
+}
+
+func (i *isolateExec) box(id int, action string) error {
+	output, err := exec.Command(i.config.Path, append(i.boxArgs(id), action)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (i *isolateExec) boxArgs(id int) []string {
+	args := []string{"--box-id=" + strconv.Itoa(id)}
+	if i.config.Cgroup {
+		args = append(args, "--cg")
+	}
+	return args
+}
+
+// runArgs translates the libjudger arguments into an isolate command line.
+// Times are in milliseconds and sizes in bytes on the libjudger side, while
+// isolate takes seconds and kilobytes.
+func (i *isolateExec) runArgs(box int, args judger.ExecArgs, metaPath string) []string {
+	argv := append(i.boxArgs(box),
+		"--meta="+metaPath,
+		"--silent",
+		"--processes="+strconv.Itoa(i.config.MaxProcesses),
+	)
+	if args.MaxCpuTime > 0 {
+		argv = append(argv, "--time="+seconds(args.MaxCpuTime))
+	}
+	if args.MaxRealTime > 0 {
+		argv = append(argv, "--wall-time="+seconds(args.MaxRealTime))
+	}
+	// MemoryLimitCheckOnly languages (e.g. Go) reserve more memory than they
+	// use, so their memory is only compared with the limit afterwards.
+	if args.MaxMemory > 0 && !args.MemoryLimitCheckOnly {
+		if i.config.Cgroup {
+			argv = append(argv, "--cg-mem="+kilobytes(args.MaxMemory))
+		} else {
+			argv = append(argv, "--mem="+kilobytes(args.MaxMemory))
+		}
+	}
+	if args.MaxStackSize > 0 {
+		argv = append(argv, "--stack="+kilobytes(args.MaxStackSize))
+	}
+	if args.MaxOutputSize > 0 {
+		argv = append(argv, "--fsize="+kilobytes(args.MaxOutputSize))
+	}
+	for _, dir := range BindDirs(args) {
+		if args.FileIo {
+			dir += ":rw"
+		}
+		argv = append(argv, "--dir="+dir)
+	}
+	argv = append(argv, "--env=PATH="+os.Getenv("PATH"))
+	for _, env := range args.Env {
+		argv = append(argv, "--env="+env)
+	}
+	argv = append(argv, "--run", "--", args.ExePath)
+	return append(argv, args.Args...)
+}
+
+// BindDirs returns the host directories a process refers to through its
+// executable, arguments and environment, e.g. its build directory, checker
+// files or the Java policy. Directories isolate mounts anyway are left out,
+// and so are those inside another returned directory.
+func BindDirs(args judger.ExecArgs) []string {
+	var dirs []string
+	for _, value := range slices.Concat([]string{args.ExePath}, args.Args, args.Env) {
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == '=' || r == ':' }) {
+			if !filepath.IsAbs(field) {
+				continue
+			}
+			info, err := os.Stat(field)
+			if err != nil {
+				continue
+			}
+			dir := filepath.Clean(field)
+			if !info.IsDir() {
+				dir = filepath.Dir(dir)
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	slices.Sort(dirs)
+	var bound []string
+	for _, dir := range dirs {
+		if dir == "/" {
+			continue
+		}
+		if !slices.ContainsFunc(slices.Concat(defaultDirs, bound), func(parent string) bool { return within(dir, parent) }) {
+			bound = append(bound, dir)
+		}
+	}
+	return bound
+}
+
+func within(dir, parent string) bool {
+	return dir == parent || strings.HasPrefix(dir, parent+"/")
+}
+
+func seconds(ms int) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 3, 64)
+}
+
+func kilobytes(bytes int) string {
+	return strconv.Itoa((bytes + 1023) / 1024)
+}
@@ -0,0 +1,192 @@
+package isolate
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+// fakeIsolate stands in for isolate. It records --init and --cleanup and the
+// arguments of runs, runs the command after "--" directly and writes a meta
+// file like isolate does.
+const fakeIsolate = `#!/bin/sh
+log="$(dirname "$0")/calls"
+echo "$*" >> "$(dirname "$0")/argv"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --init|--cleanup) echo "$1" >> "$log"; exit 0 ;;
+    --meta=*) meta="${1#--meta=}" ;;
+    --) shift; break ;;
+  esac
+  shift
+done
+"$@"
+status=$?
+printf 'time:0.012\ntime-wall:0.020\nmax-rss:1024\nexitcode:%d\n' $status > "$meta"
+if [ $status -ne 0 ]; then
+  echo "status:RE" >> "$meta"
+  exit 1
+fi
+`
+
+func newFakeIsolate(t *testing.T) (*isolateExec, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "isolate.sh")
+	require.NoError(t, os.WriteFile(path, []byte(fakeIsolate), 0o755))
+	return NewIsolateExec(Config{Path: path, BoxCount: 1, MaxProcesses: 1}, noopLogger{}), dir
+}
+
+func TestExec(t *testing.T) {
+	t.Run("runs the process in a box", func(t *testing.T) {
+		isolateExec, dir := newFakeIsolate(t)
+		args := judger.ExecArgs{
+			ExePath:    "/bin/cat",
+			OutputPath: filepath.Join(dir, "0.out"),
+			ErrorPath:  filepath.Join(dir, "0.error"),
+		}
+
+		res, err := isolateExec.Exec(args, []byte("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.RUN_SUCCESS, res.StatusCode)
+		assert.Equal(t, 12, res.CpuTime)
+		assert.Equal(t, 1024*1024, res.Memory)
+
+		output, err := os.ReadFile(args.OutputPath)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(output))
+		calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+		require.NoError(t, err)
+		assert.Equal(t, "--init\n--cleanup\n", string(calls))
+	})
+
+	t.Run("reads stdin from the input path", func(t *testing.T) {
+		isolateExec, dir := newFakeIsolate(t)
+		inputPath := filepath.Join(dir, "0.in")
+		require.NoError(t, os.WriteFile(inputPath, []byte("from file"), 0o644))
+		args := judger.ExecArgs{ExePath: "/bin/cat", InputPath: inputPath, OutputPath: filepath.Join(dir, "0.out")}
+
+		_, err := isolateExec.Exec(args, []byte("ignored"))
+		require.NoError(t, err)
+		output, err := os.ReadFile(args.OutputPath)
+		require.NoError(t, err)
+		assert.Equal(t, "from file", string(output))
+	})
+
+	t.Run("compiles into the writable build directory", func(t *testing.T) {
+		if _, err := exec.LookPath("gcc"); err != nil {
+			t.Skip("gcc not installed")
+		}
+		isolateExec, dir := newFakeIsolate(t)
+		unitDir := filepath.Join(dir, "unit")
+		require.NoError(t, os.Mkdir(unitDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(unitDir, "main.c"), []byte("int main(void) { return 0; }\n"), 0o644))
+		args, err := judger.NewJudgerLangConfig(file.NewFileManager(dir), "/policy").ToCompileExecArgs("unit", sandbox.C)
+		require.NoError(t, err)
+
+		res, err := isolateExec.Exec(args, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.RUN_SUCCESS, res.StatusCode)
+		assert.FileExists(t, filepath.Join(unitDir, "main"))
+		argv, err := os.ReadFile(filepath.Join(dir, "argv"))
+		require.NoError(t, err)
+		assert.Contains(t, string(argv), "--dir="+unitDir+":rw")
+	})
+
+	t.Run("writes stdout and stderr to a shared file once", func(t *testing.T) {
+		isolateExec, dir := newFakeIsolate(t)
+		outputPath := filepath.Join(dir, "compile.out")
+		args := judger.ExecArgs{
+			ExePath:    "/bin/sh",
+			Args:       []string{"-c", "echo warning >&2; echo done"},
+			OutputPath: outputPath,
+			ErrorPath:  outputPath,
+		}
+
+		_, err := isolateExec.Exec(args, nil)
+		require.NoError(t, err)
+		output, err := os.ReadFile(outputPath)
+		require.NoError(t, err)
+		assert.Equal(t, "warning\ndone\n", string(output))
+	})
+
+	t.Run("reports failing programs as runtime errors", func(t *testing.T) {
+		isolateExec, dir := newFakeIsolate(t)
+		args := judger.ExecArgs{ExePath: "/bin/false", OutputPath: filepath.Join(dir, "0.out")}
+
+		res, err := isolateExec.Exec(args, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.RUNTIME_ERROR, res.StatusCode)
+		assert.Equal(t, 1, res.ExitCode)
+	})
+}
+
+func TestRunArgs(t *testing.T) {
+	dir := t.TempDir()
+	isolateExec := NewIsolateExec(Config{Path: "isolate", BoxCount: 1, Cgroup: true, MaxProcesses: 8}, noopLogger{})
+	args := judger.ExecArgs{
+		ExePath:      filepath.Join(dir, "main"),
+		MaxCpuTime:   1500,
+		MaxRealTime:  4500,
+		MaxMemory:    256 * 1024 * 1024,
+		MaxStackSize: 128 * 1024 * 1024,
+		Args:         []string{"--flag"},
+		Env:          []string{"LANG=C"},
+		FileIo:       true,
+	}
+	require.NoError(t, os.WriteFile(args.ExePath, nil, 0o755))
+
+	argv := strings.Join(isolateExec.runArgs(3, args, "/meta"), " ")
+	assert.Contains(t, argv, "--box-id=3 --cg --meta=/meta --silent --processes=8")
+	assert.Contains(t, argv, "--time=1.500 --wall-time=4.500 --cg-mem=262144 --stack=131072")
+	assert.Contains(t, argv, "--dir="+dir+":rw")
+	assert.Contains(t, argv, "--env=LANG=C")
+	assert.True(t, strings.HasSuffix(argv, "--run -- "+args.ExePath+" --flag"))
+
+	t.Run("only checks the memory of check-only languages", func(t *testing.T) {
+		args := args
+		args.MemoryLimitCheckOnly = true
+		assert.NotContains(t, strings.Join(isolateExec.runArgs(3, args, "/meta"), " "), "--cg-mem")
+	})
+}
+
+func TestBindDirs(t *testing.T) {
+	dir := t.TempDir()
+	unit := filepath.Join(dir, "unit")
+	policy := filepath.Join(dir, "policy", "java_policy")
+	require.NoError(t, os.MkdirAll(unit, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(policy), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(unit, "Main.jar"), nil, 0o644))
+	require.NoError(t, os.WriteFile(policy, nil, 0o644))
+
+	dirs := BindDirs(judger.ExecArgs{
+		ExePath: "/usr/bin/java",
+		Args: []string{
+			"-cp", filepath.Join(unit, "Main.jar"),
+			"-Djava.security.policy==" + policy,
+			filepath.Join(dir, "missing"),
+			"Main",
+		},
+		Env: []string{"PYTHONPATH=" + unit + "/"},
+	})
+	assert.Equal(t, []string{filepath.Dir(policy), unit}, dirs)
+
+	t.Run("skips directories inside others", func(t *testing.T) {
+		assert.Equal(t, []string{dir}, BindDirs(judger.ExecArgs{ExePath: filepath.Join(unit, "Main.jar"), Args: []string{dir}}))
+	})
+}
@@ -0,0 +1,26 @@
+package isolate
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+)
+
+// NewIsolateSandboxImpl runs compilers, solutions and tools in isolate boxes
+// with the same language configs as the libjudger sandbox, so that both can
+// be compared on the same submissions. Compilers get the build directory
+// mounted writable to store the executable.
+func NewIsolateSandboxImpl(fileManager file.FileManager, logProvider logger.Logger) (sandbox.Sandbox[judger.JudgerConfig, judger.ExecArgs], error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(config.Path); err != nil {
+		return nil, fmt.Errorf("isolate not found: %w", err)
+	}
+	return judger.NewSandboxWithExec(NewIsolateExec(config, logProvider), fileManager, logProvider)
+}
@@ -0,0 +1,80 @@
+package isolate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+)
+
+// isolate meta file statuses (see isolate(1))
+const (
+	statusRuntimeError = "RE"
+	statusSignaled     = "SG"
+	statusTimedOut     = "TO"
+	statusInternal     = "XX"
+)
+
+// ParseMeta reads the "key:value" lines isolate writes to its meta file into
+// the result libjudger would have reported for args: times in milliseconds,
+// memory in bytes, and limit verdicts in the same order of precedence.
+func ParseMeta(data []byte, args judger.ExecArgs) (sandbox.ExecResult, error) {
+	meta := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			meta[key] = value
+		}
+	}
+	if meta["status"] == statusInternal {
+		return sandbox.ExecResult{}, fmt.Errorf("isolate internal error: %s", meta["message"])
+	}
+
+	res := sandbox.ExecResult{
+		CpuTime:  milliseconds(meta["time"]),
+		RealTime: milliseconds(meta["time-wall"]),
+		Signal:   atoi(meta["exitsig"]),
+		ExitCode: atoi(meta["exitcode"]),
+	}
+	if memory, ok := meta["cg-mem"]; ok {
+		res.Memory = atoi(memory) * 1024
+	} else {
+		res.Memory = atoi(meta["max-rss"]) * 1024
+	}
+
+	memoryExceeded := meta["cg-oom-killed"] == "1" || (args.MaxMemory > 0 && res.Memory > args.MaxMemory)
+	switch {
+	case meta["status"] == statusTimedOut:
+		if strings.Contains(meta["message"], "wall") {
+			res.StatusCode = sandbox.REAL_TIME_LIMIT_EXCEEDED
+		} else {
+			res.StatusCode = sandbox.CPU_TIME_LIMIT_EXCEEDED
+		}
+	case memoryExceeded:
+		res.StatusCode = sandbox.MEMORY_LIMIT_EXCEEDED
+	case meta["status"] == statusSignaled && res.Signal == int(syscall.SIGSEGV):
+		res.StatusCode = sandbox.SEGMENTATION_FAULT_ERROR
+	case meta["status"] == statusSignaled && res.Signal == int(syscall.SIGXFSZ):
+		res.StatusCode = sandbox.OUTPUT_LIMIT_EXCEEDED
+	case meta["status"] == statusRuntimeError, meta["status"] == statusSignaled:
+		res.StatusCode = sandbox.RUNTIME_ERROR
+	default:
+		res.StatusCode = sandbox.RUN_SUCCESS
+	}
+	return res, nil
+}
+
+func milliseconds(value string) int {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int(seconds*1000 + 0.5)
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
@@ -0,0 +1,56 @@
+package isolate
+
+import (
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox/judger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMeta(t *testing.T) {
+	limits := judger.ExecArgs{MaxMemory: 64 * 1024 * 1024}
+
+	tests := []struct {
+		name   string
+		meta   string
+		args   judger.ExecArgs
+		status sandbox.StatusCode
+	}{
+		{"success", "time:0.012\ntime-wall:0.020\nmax-rss:1024\nexitcode:0\n", limits, sandbox.RUN_SUCCESS},
+		{"cpu time limit", "status:TO\nmessage:Time limit exceeded\ntime:1.001\n", limits, sandbox.CPU_TIME_LIMIT_EXCEEDED},
+		{"wall time limit", "status:TO\nmessage:Time limit exceeded (wall clock)\n", limits, sandbox.REAL_TIME_LIMIT_EXCEEDED},
+		{"killed by the cgroup", "status:SG\nexitsig:9\ncg-mem:65536\ncg-oom-killed:1\n", limits, sandbox.MEMORY_LIMIT_EXCEEDED},
+		{"memory checked afterwards", "cg-mem:131072\nexitcode:0\n", judger.ExecArgs{MaxMemory: 64 * 1024 * 1024, MemoryLimitCheckOnly: true}, sandbox.MEMORY_LIMIT_EXCEEDED},
+		{"nonzero exit code", "status:RE\nexitcode:1\nmessage:Exited with error status 1\n", limits, sandbox.RUNTIME_ERROR},
+		{"signal", "status:SG\nexitsig:8\nmessage:Caught fatal signal 8\n", limits, sandbox.RUNTIME_ERROR},
+		{"segmentation fault", "status:SG\nexitsig:11\nmessage:Caught fatal signal 11\n", limits, sandbox.SEGMENTATION_FAULT_ERROR},
+		{"output limit", "status:SG\nexitsig:25\nmessage:Caught fatal signal 25\n", limits, sandbox.OUTPUT_LIMIT_EXCEEDED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ParseMeta([]byte(tt.meta), tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+
+	t.Run("converts units", func(t *testing.T) {
+		res, err := ParseMeta([]byte("time:0.012\ntime-wall:0.020\nmax-rss:1024\nexitcode:3\nexitsig:0\n"), limits)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.ExecResult{CpuTime: 12, RealTime: 20, Memory: 1024 * 1024, ExitCode: 3}, res)
+	})
+
+	t.Run("prefers cgroup memory", func(t *testing.T) {
+		res, err := ParseMeta([]byte("max-rss:1024\ncg-mem:2048\n"), limits)
+		require.NoError(t, err)
+		assert.Equal(t, 2048*1024, res.Memory)
+	})
+
+	t.Run("reports internal errors", func(t *testing.T) {
+		_, err := ParseMeta([]byte("status:XX\nmessage:Cannot run proxy\n"), limits)
+		assert.EqualError(t, err, "isolate internal error: Cannot run proxy")
+	})
+}
@@ -0,0 +1,43 @@
+package judger
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// StartPinned starts cmd restricted to cpuSet. A forked process inherits the
+// affinity of the OS thread that forks it, so the goroutine is locked to its
+// thread while that thread's mask is swapped for the duration of Start.
+func StartPinned(cmd *exec.Cmd, cpuSet []int) error {
+	if len(cpuSet) == 0 {
+		return cmd.Start()
+	}
+
+	runtime.LockOSThread()
+
+	var original unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &original); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("getting cpu affinity: %w", err)
+	}
+	var pinned unix.CPUSet
+	for _, cpu := range cpuSet {
+		pinned.Set(cpu)
+	}
+	if err := unix.SchedSetaffinity(0, &pinned); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("setting cpu affinity: %w", err)
+	}
+
+	err := cmd.Start()
+
+	// If the mask cannot be restored the thread stays locked, so that the
+	// runtime discards it with this goroutine instead of reusing it pinned.
+	if restoreErr := unix.SchedSetaffinity(0, &original); restoreErr == nil {
+		runtime.UnlockOSThread()
+	}
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"syscall"
 
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
@@ -14,8 +15,13 @@ import (
 
 type JudgerExec interface {
 	Exec(args ExecArgs, input []byte) (sandbox.ExecResult, error)
+	ExecPiped(args ExecArgs, stdin *os.File, stdout *os.File) (sandbox.ExecResult, error)
 }
 
+// pipedOutputPath is where the sandboxed process finds the stdout pipe passed
+// through ExtraFiles (the first extra file is always fd 3).
+const pipedOutputPath = "/dev/fd/3"
+
 type judgerExec struct {
 	binaryPath string
 	logger     logger.Logger
@@ -30,38 +36,89 @@ func NewJudgerExec(binaryPath string, logger logger.Logger) *judgerExec {
 }
 
 func (j *judgerExec) Exec(args ExecArgs, input []byte) (sandbox.ExecResult, error) {
-	argSlice := makeExecArgs(args)
-	env := "--env=PATH=" + os.Getenv("PATH")
-	argSlice = append(argSlice, env)
-	cmd := exec.Command(j.binaryPath, argSlice...)
+	cmd := j.command(args)
 
-	var stdin bytes.Buffer
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	cmd.Stdin = &stdin
+	// libjudger opens InputPath itself, so input is not copied through a pipe
+	if args.InputPath == "" {
+		cmd.Stdin = bytes.NewReader(input)
+	}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	stdin.Write(input)
 
-	err := cmd.Run()
+	err := StartPinned(cmd, args.CpuSet)
+	if err == nil {
+		err = cmd.Wait()
+	}
+	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("sandbox execution failed: %w: %s", err, stderr.String())
+	}
+
+	return j.parseResult(stdout.Bytes())
+}
+
+// ExecPiped runs the sandbox with its stdin and stdout bound to the given pipe
+// ends instead of a buffer and an output file. It takes ownership of both
+// files and closes them once the sandbox process has started, so that the
+// peer reading from stdout sees EOF as soon as the sandbox exits.
+func (j *judgerExec) ExecPiped(args ExecArgs, stdin *os.File, stdout *os.File) (sandbox.ExecResult, error) {
+	args.OutputPath = pipedOutputPath
+	cmd := j.command(args)
+
+	var result bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = &result
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{stdout}
+
+	err := StartPinned(cmd, args.CpuSet)
+	stdin.Close()
+	stdout.Close()
 	if err != nil {
+		return sandbox.ExecResult{}, fmt.Errorf("sandbox execution failed: %w", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
 		return sandbox.ExecResult{}, fmt.Errorf("sandbox execution failed: %w: %s", err, stderr.String())
 	}
 
+	return j.parseResult(result.Bytes())
+}
+
+func (j *judgerExec) command(args ExecArgs) *exec.Cmd {
+	argSlice := makeExecArgs(args)
+	env := "--env=PATH=" + os.Getenv("PATH")
+	argSlice = append(argSlice, env)
+	return exec.Command(j.binaryPath, argSlice...)
+}
+
+func (j *judgerExec) parseResult(data []byte) (sandbox.ExecResult, error) {
 	res := sandbox.ExecResult{}
-	j.logger.Log(logger.DEBUG, fmt.Sprintf("sandbox result: %s", stdout.String()))
-	err = json.Unmarshal(stdout.Bytes(), &res)
+	j.logger.Log(logger.DEBUG, fmt.Sprintf("sandbox result: %s", string(data)))
+	err := json.Unmarshal(data, &res)
 	if err != nil {
 		return sandbox.ExecResult{}, fmt.Errorf("failed to unmarshal sandbox result: %w", err)
 	}
 
 	res.StatusCode = judgerResultCodeToSandboxStatusCode(ResultCode(res.StatusCode))
+	if res.StatusCode == sandbox.RUNTIME_ERROR {
+		switch syscall.Signal(res.Signal) {
+		case syscall.SIGSEGV:
+			res.StatusCode = sandbox.SEGMENTATION_FAULT_ERROR
+		case syscall.SIGXFSZ:
+			res.StatusCode = sandbox.OUTPUT_LIMIT_EXCEEDED
+		}
+	}
 
 	return res, nil
 }
 
 func judgerResultCodeToSandboxStatusCode(resultCode ResultCode) sandbox.StatusCode {
 	switch resultCode {
+	case RUN_SUCCESS:
+		return sandbox.RUN_SUCCESS
 	case CPU_TIME_LIMIT_EXCEEDED:
 		return sandbox.CPU_TIME_LIMIT_EXCEEDED
 	case REAL_TIME_LIMIT_EXCEEDED:
@@ -74,7 +131,8 @@ func judgerResultCodeToSandboxStatusCode(resultCode ResultCode) sandbox.StatusCo
 		return sandbox.SERVER_ERROR
 	}
 
-	return sandbox.RUN_SUCCESS
+	// an unknown result must not pass for a successful run
+	return sandbox.SERVER_ERROR
 }
 
 type ExecArgs struct {
@@ -94,6 +152,12 @@ type ExecArgs struct {
 	Env                  []string
 	Uid                  int
 	Gid                  int
+	// CpuSet is applied to the judger process itself, not passed as a flag
+	CpuSet []int
+	// FileIo is set for processes that may write files (e.g. compilers and
+	// interactors). libjudger allows it through SeccompRuleName, other
+	// backends need it to mount the build directory writable.
+	FileIo bool
 }
 
 const (
@@ -0,0 +1,116 @@
+package judger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/logger"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Log(_ logger.Level, _ string)                               {}
+func (noopLogger) LogWithContext(_ logger.Level, _ string, _ context.Context) {}
+func (noopLogger) Panic(_ string)                                             {}
+
+// fakeJudger stands in for libjudger. Called with --args=<greeting> and
+// --args=<file>, it writes the greeting to its output path, stores the line
+// read from stdin in the file and prints a judger result.
+const fakeJudger = `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    --output_path=*) output="${arg#--output_path=}" ;;
+    --args=*) if [ -z "$greeting" ]; then greeting="${arg#--args=}"; else received="${arg#--args=}"; fi ;;
+  esac
+done
+echo "$greeting" > "$output"
+read line
+echo "$line" > "$received"
+echo '{"cpu_time":1,"real_time":2,"memory":3,"signal":0,"exit_code":0,"error":0,"result":0}'
+`
+
+func TestExecPipedCrossWiresTwoSandboxes(t *testing.T) {
+	dir := t.TempDir()
+	binaryPath := filepath.Join(dir, "judger.sh")
+	require.NoError(t, os.WriteFile(binaryPath, []byte(fakeJudger), 0o755))
+
+	aToB, aWrites, err := os.Pipe()
+	require.NoError(t, err)
+	bToA, bWrites, err := os.Pipe()
+	require.NoError(t, err)
+
+	judgerExec := NewJudgerExec(binaryPath, noopLogger{})
+	var wg sync.WaitGroup
+	var resA, resB sandbox.ExecResult
+	var errA, errB error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		args := ExecArgs{Uid: -1, Gid: -1, Args: []string{"from-a", filepath.Join(dir, "a")}}
+		resA, errA = judgerExec.ExecPiped(args, bToA, aWrites)
+	}()
+	go func() {
+		defer wg.Done()
+		args := ExecArgs{Uid: -1, Gid: -1, Args: []string{"from-b", filepath.Join(dir, "b")}}
+		resB, errB = judgerExec.ExecPiped(args, aToB, bWrites)
+	}()
+	wg.Wait()
+
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+	assert.Equal(t, 3, resA.Memory)
+	assert.Equal(t, sandbox.RUN_SUCCESS, resB.StatusCode)
+
+	receivedByA, err := os.ReadFile(filepath.Join(dir, "a"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-b\n", string(receivedByA))
+	receivedByB, err := os.ReadFile(filepath.Join(dir, "b"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-a\n", string(receivedByB))
+}
+
+func TestParseResult(t *testing.T) {
+	j := NewJudgerExec("", noopLogger{})
+
+	tests := []struct {
+		name   string
+		result string
+		status sandbox.StatusCode
+	}{
+		{"success", `{"signal":0,"exit_code":0,"result":0}`, sandbox.RUN_SUCCESS},
+		{"nonzero exit code", `{"signal":0,"exit_code":1,"result":4}`, sandbox.RUNTIME_ERROR},
+		{"floating point exception", `{"signal":8,"exit_code":0,"result":4}`, sandbox.RUNTIME_ERROR},
+		{"segmentation fault", `{"signal":11,"exit_code":0,"result":4}`, sandbox.SEGMENTATION_FAULT_ERROR},
+		{"output limit", `{"signal":25,"exit_code":0,"result":4}`, sandbox.OUTPUT_LIMIT_EXCEEDED},
+		{"killed at the memory limit", `{"signal":11,"exit_code":0,"result":3}`, sandbox.MEMORY_LIMIT_EXCEEDED},
+		{"unknown result", `{"signal":0,"exit_code":0,"result":9}`, sandbox.SERVER_ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := j.parseResult([]byte(tt.result))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+}
+
+func TestOutputLimitReached(t *testing.T) {
+	dir := t.TempDir()
+	args := ExecArgs{MaxOutputSize: 4, OutputPath: filepath.Join(dir, "0.out"), ErrorPath: filepath.Join(dir, "0.error")}
+	require.NoError(t, os.WriteFile(args.OutputPath, []byte("abc"), 0o644))
+	require.NoError(t, os.WriteFile(args.ErrorPath, []byte(""), 0o644))
+	assert.False(t, outputLimitReached(args))
+
+	require.NoError(t, os.WriteFile(args.ErrorPath, []byte("abcd"), 0o644))
+	assert.True(t, outputLimitReached(args))
+
+	args.MaxOutputSize = 0
+	assert.False(t, outputLimitReached(args))
+}
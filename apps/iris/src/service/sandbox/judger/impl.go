@@ -1,6 +1,8 @@
 package judger
 
 import (
+	"os"
+
 	"github.com/skkuding/codedang/apps/iris/src/service/file"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
@@ -10,24 +12,40 @@ import (
 type judgerSandboxImpl struct {
 	sandbox.Compiler
 	sandbox.Runner
+	sandbox.Interactor
 	sandbox.LangConfig[JudgerConfig, ExecArgs]
 }
 
-func NewJudgerSandboxImpl(fileManager file.FileManager, logProvider logger.Logger) sandbox.Sandbox[JudgerConfig, ExecArgs] {
+func NewJudgerSandboxImpl(fileManager file.FileManager, logProvider logger.Logger) (sandbox.Sandbox[JudgerConfig, ExecArgs], error) {
+	libjudgerPath := string(utils.Getenv("LIBJUDGER_PATH", "/app/sandbox/libjudger.so"))
+	return NewSandboxWithExec(NewJudgerExec(libjudgerPath, logProvider), fileManager, logProvider)
+}
+
+// NewSandboxWithExec builds the sandbox around judgerExec, so that another
+// backend (e.g. isolate) can run the processes while languages are
+// configured the same way as for libjudger.
+func NewSandboxWithExec(judgerExec JudgerExec, fileManager file.FileManager, logProvider logger.Logger) (sandbox.Sandbox[JudgerConfig, ExecArgs], error) {
 	// load env
 	javaPolicyPath := string(utils.Getenv("JAVA_POLICY_PATH", "/app/sandbox/policy/java_policy"))
-	libjudgerPath := string(utils.Getenv("LIBJUDGER_PATH", "/app/sandbox/libjudger.so"))
+	languageConfigPath := os.Getenv(LanguageConfigPathEnv)
 
 	langConfig := NewJudgerLangConfig(fileManager, javaPolicyPath)
-	sb := NewJudgerExec(libjudgerPath, logProvider)
-	compiler := NewJudgerCompiler(sb, langConfig, fileManager, logProvider)
-	runner := NewJudgerRunner(sb, langConfig, fileManager, logProvider)
+	if languageConfigPath != "" {
+		var err error
+		langConfig, err = NewJudgerLangConfigFromFile(fileManager, javaPolicyPath, languageConfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	compiler := NewJudgerCompiler(judgerExec, langConfig, fileManager, logProvider)
+	runner := NewJudgerRunner(judgerExec, langConfig, fileManager, logProvider)
 
 	sandbox := judgerSandboxImpl{
 		compiler,
 		runner,
+		runner,
 		langConfig,
 	}
 
-	return &sandbox
+	return &sandbox, nil
 }
@@ -2,44 +2,72 @@ package judger
 
 import (
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 
 	"github.com/skkuding/codedang/apps/iris/src/common/constants"
 	"github.com/skkuding/codedang/apps/iris/src/service/file"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
 )
 
 type langConfig struct {
-	cConfig    JudgerConfig
-	cppConfig  JudgerConfig
-	javaConfig JudgerConfig
-	pyConfig   JudgerConfig
-	pypyConfig JudgerConfig
-	file       file.FileManager
+	configs map[sandbox.Language]JudgerConfig
+	file    file.FileManager
 }
 
 type JudgerConfig struct {
-	Language              sandbox.Language
-	SrcName               string
-	ExeName               string
-	MaxCompileCpuTime     int
-	MaxCompileRealTime    int
-	MaxCompileMemory      int
-	CompilerPath          string
-	CompileArgs           string
-	RunCommand            string
-	RunArgs               string
-	SeccompRule           string
-	SeccompRuleFileIO     string
-	MemoeryLimitCheckOnly bool
-	env                   []string
+	Language              sandbox.Language `yaml:"language"`
+	SrcName               string           `yaml:"srcName"`
+	ExeName               string           `yaml:"exeName"`
+	MaxCompileCpuTime     int              `yaml:"maxCompileCpuTime"`
+	MaxCompileRealTime    int              `yaml:"maxCompileRealTime"`
+	MaxCompileMemory      int              `yaml:"maxCompileMemory"`
+	CompilerPath          string           `yaml:"compilerPath"`
+	CompileArgs           string           `yaml:"compileArgs"`
+	RunCommand            string           `yaml:"runCommand"`
+	RunArgs               string           `yaml:"runArgs"`
+	SeccompRule           string           `yaml:"seccompRule"`
+	SeccompRuleFileIO     string           `yaml:"seccompRuleFileIO"`
+	MemoeryLimitCheckOnly bool             `yaml:"memoryLimitCheckOnly"`
+	Env                   []string         `yaml:"env"` // added to PATH for runs; the built-in languages set none
+	CompileEnv            []string         `yaml:"compileEnv"`
+	// TimeScaling gives the language more time than the problem's limit
+	TimeScaling sandbox.TimeScaling `yaml:"timeScaling"`
 }
 
 func NewJudgerLangConfig(file file.FileManager, javaPolicyPath string) *langConfig {
+	return newLangConfig(file, DefaultJudgerConfigs(javaPolicyPath))
+}
+
+// NewJudgerLangConfigFromFile starts from the built-in languages and applies
+// the registry at path on top: an entry replaces the built-in config of the
+// same language, any other entry adds a language.
+func NewJudgerLangConfigFromFile(file file.FileManager, javaPolicyPath string, path string) (*langConfig, error) {
+	configs, err := LoadJudgerConfigs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		sandbox.RegisterLanguage(c.Language)
+	}
+	return newLangConfig(file, append(DefaultJudgerConfigs(javaPolicyPath), configs...)), nil
+}
+
+func newLangConfig(file file.FileManager, configs []JudgerConfig) *langConfig {
+	l := &langConfig{
+		configs: make(map[sandbox.Language]JudgerConfig, len(configs)),
+		file:    file,
+	}
+	for _, c := range configs {
+		l.configs[c.Language] = c
+	}
+	return l
+}
+
+// DefaultJudgerConfigs returns the built-in language set.
+func DefaultJudgerConfigs(javaPolicyPath string) []JudgerConfig {
 	defaultEnv := []string{"LANG=en_US.UTF-8", "LANGUAGE=en_US:en", "LC_ALL=en_US.UTF-8"}
 	var cConfig = JudgerConfig{
 		Language:           sandbox.C,
@@ -57,72 +85,78 @@ func NewJudgerLangConfig(file file.FileManager, javaPolicyPath string) *langConf
 		SeccompRule:           "c_cpp",
 		SeccompRuleFileIO:     "c_cpp_file_io",
 		MemoeryLimitCheckOnly: false,
-		env:                   defaultEnv,
 	}
 
-	var cppConfig = JudgerConfig{
-		Language:           sandbox.CPP,
-		SrcName:            "main.cpp",
-		ExeName:            "main",
-		MaxCompileCpuTime:  10000,
-		MaxCompileRealTime: 20000,
-		MaxCompileMemory:   1024 * 1024 * 1024,
-		CompilerPath:       "/usr/bin/g++",
-		CompileArgs: "-DONLINE_JUDGE " +
-			"-O2 -Wall -std=c++14 {srcPath} -lm -o {exePath}",
-		RunCommand:            "{exePath}",
-		RunArgs:               "",
-		SeccompRule:           "c_cpp",
-		SeccompRuleFileIO:     "c_cpp_file_io",
-		MemoeryLimitCheckOnly: false,
-		env:                   defaultEnv,
+	cppConfig := func(language sandbox.Language, std string) JudgerConfig {
+		return JudgerConfig{
+			Language:           language,
+			SrcName:            "main.cpp",
+			ExeName:            "main",
+			MaxCompileCpuTime:  10000,
+			MaxCompileRealTime: 20000,
+			MaxCompileMemory:   1024 * 1024 * 1024,
+			CompilerPath:       "/usr/bin/g++",
+			CompileArgs: "-DONLINE_JUDGE " +
+				"-O2 -Wall -std=" + std + " {srcPath} -lm -o {exePath}",
+			RunCommand:            "{exePath}",
+			RunArgs:               "",
+			SeccompRule:           "c_cpp",
+			SeccompRuleFileIO:     "c_cpp_file_io",
+			MemoeryLimitCheckOnly: false,
+		}
 	}
 
+	// Each JDK lives in its own directory; JAVA_PATH is the default JDK and
+	// serves as fallback for the versioned ones.
 	javaPath := os.Getenv("JAVA_PATH")
+	// JVM startup counts against the wall clock but is not the solution's
+	// fault, so JVM languages get a second more of real time
+	jvmTimeScaling := sandbox.TimeScaling{RealTimeGrace: 1000}
 
-	var javaConfig = JudgerConfig{
-		Language:           sandbox.JAVA,
-		SrcName:            "Main.java",
-		ExeName:            "Main",
-		MaxCompileCpuTime:  5000,
-		MaxCompileRealTime: 10000,
-		MaxCompileMemory:   -1,
-		CompilerPath:       fmt.Sprintf("%s/javac", javaPath),
-		CompileArgs:        "{srcPath} -d {exeDir} -encoding UTF8",
-		RunCommand:         fmt.Sprintf("%s/java", javaPath),
-		RunArgs: "-cp {exeDir} " +
-			"-Djava.security.manager " +
-			"-Dfile.encoding=UTF-8 " +
-			"-Djava.security.policy==" +
-			javaPolicyPath + " " +
-			"-Djava.awt.headless=true " +
-			"-XX:+UseSerialGC " +
-			"Main",
-		SeccompRule:           "",
-		MemoeryLimitCheckOnly: false,
-		env:                   defaultEnv,
-	}
-
-	pythonVersion, err := GetPythonVersion()
-	if err != nil {
-		pythonVersion = "312"
+	javaConfig := func(language sandbox.Language, pathEnv string) JudgerConfig {
+		jdkPath := string(utils.Getenv(pathEnv, javaPath))
+		return JudgerConfig{
+			Language:           language,
+			SrcName:            "Main.java",
+			ExeName:            "Main",
+			MaxCompileCpuTime:  5000,
+			MaxCompileRealTime: 10000,
+			MaxCompileMemory:   -1,
+			CompilerPath:       fmt.Sprintf("%s/javac", jdkPath),
+			CompileArgs:        "{srcPath} -d {exeDir} -encoding UTF8",
+			RunCommand:         fmt.Sprintf("%s/java", jdkPath),
+			RunArgs: "-cp {exeDir} " +
+				"-Djava.security.manager " +
+				"-Dfile.encoding=UTF-8 " +
+				"-Djava.security.policy==" +
+				javaPolicyPath + " " +
+				"-Djava.awt.headless=true " +
+				"-XX:+UseSerialGC " +
+				"Main",
+			SeccompRule:           "",
+			MemoeryLimitCheckOnly: false,
+			TimeScaling:           jvmTimeScaling,
+		}
 	}
 
-	var pyConfig = JudgerConfig{
-		Language: sandbox.PYTHON,
-		SrcName:  "solution.py",
-		ExeName:  fmt.Sprintf("__pycache__/solution.cpython-%s.pyc", pythonVersion),
-		// ExeName:               "__pycache__/solution.cpython-312.pyc", // Default Version on Debian 11 (bullseye)
-		MaxCompileCpuTime:     3000,
-		MaxCompileRealTime:    10000,
-		MaxCompileMemory:      128 * 1024 * 1024,
-		CompilerPath:          "/usr/bin/python3",
-		CompileArgs:           "-m py_compile {srcPath}",
-		RunCommand:            "/usr/bin/python3",
-		RunArgs:               "{exePath}",
-		SeccompRule:           "general",
-		MemoeryLimitCheckOnly: false,
-		env:                   append(defaultEnv, "PYTHONIOENCODING=utf-8"),
+	// version is the major and minor version without the dot, as used in the
+	// names of compiled files
+	pyConfig := func(language sandbox.Language, version string) JudgerConfig {
+		pythonPath := fmt.Sprintf("/usr/bin/python%s.%s", version[:1], version[1:])
+		return JudgerConfig{
+			Language:              language,
+			SrcName:               "solution.py",
+			ExeName:               fmt.Sprintf("__pycache__/solution.cpython-%s.pyc", version),
+			MaxCompileCpuTime:     3000,
+			MaxCompileRealTime:    10000,
+			MaxCompileMemory:      128 * 1024 * 1024,
+			CompilerPath:          pythonPath,
+			CompileArgs:           "-m py_compile {srcPath}",
+			RunCommand:            pythonPath,
+			RunArgs:               "{exePath}",
+			SeccompRule:           "general",
+			MemoeryLimitCheckOnly: false,
+		}
 	}
 
 	var pypyConfig = JudgerConfig{
@@ -138,33 +172,112 @@ func NewJudgerLangConfig(file file.FileManager, javaPolicyPath string) *langConf
 		RunArgs:               "{exePath}",
 		SeccompRule:           "general",
 		MemoeryLimitCheckOnly: false,
-		env:                   append(defaultEnv, "PYTHONIOENCODING=utf-8", "PYTHONPATH={exeDir}"),
 	}
 
-	return &langConfig{
-		cConfig:    cConfig,
-		cppConfig:  cppConfig,
-		javaConfig: javaConfig,
-		pyConfig:   pyConfig,
-		pypyConfig: pypyConfig,
-		file:       file,
+	// Go and Node.js reserve far more virtual memory than they use, so only
+	// the actual usage is checked against the memory limit. Both spawn
+	// threads, which the general seccomp rule forbids, so they run under
+	// libjudger's golang and node rules, which allow clone for threads only.
+	var goConfig = JudgerConfig{
+		Language:              sandbox.GO,
+		SrcName:               "main.go",
+		ExeName:               "main",
+		MaxCompileCpuTime:     10000,
+		MaxCompileRealTime:    20000,
+		MaxCompileMemory:      1024 * 1024 * 1024,
+		CompilerPath:          "/usr/bin/go",
+		CompileArgs:           "build -o {exePath} {srcPath}",
+		RunCommand:            "{exePath}",
+		RunArgs:               "",
+		SeccompRule:           "golang",
+		MemoeryLimitCheckOnly: true,
+		CompileEnv:            append(defaultEnv, "GOCACHE=/tmp/go-cache", "GOPATH=/tmp/go"),
+	}
+
+	var rustConfig = JudgerConfig{
+		Language:              sandbox.RUST,
+		SrcName:               "main.rs",
+		ExeName:               "main",
+		MaxCompileCpuTime:     10000,
+		MaxCompileRealTime:    20000,
+		MaxCompileMemory:      1024 * 1024 * 1024,
+		CompilerPath:          "/usr/bin/rustc",
+		CompileArgs:           "--edition 2021 -O --cfg online_judge -o {exePath} {srcPath}",
+		RunCommand:            "{exePath}",
+		RunArgs:               "",
+		SeccompRule:           "general",
+		MemoeryLimitCheckOnly: false,
+	}
+
+	kotlinPath := os.Getenv("KOTLIN_PATH")
+
+	var kotlinConfig = JudgerConfig{
+		Language:           sandbox.KOTLIN,
+		SrcName:            "Main.kt",
+		ExeName:            "Main.jar",
+		MaxCompileCpuTime:  20000,
+		MaxCompileRealTime: 40000,
+		MaxCompileMemory:   -1,
+		CompilerPath:       fmt.Sprintf("%s/kotlinc", kotlinPath),
+		CompileArgs:        "{srcPath} -include-runtime -d {exePath}",
+		RunCommand:         fmt.Sprintf("%s/java", javaPath),
+		RunArgs: "-cp {exePath} " +
+			"-Djava.security.manager " +
+			"-Dfile.encoding=UTF-8 " +
+			"-Djava.security.policy==" +
+			javaPolicyPath + " " +
+			"-Djava.awt.headless=true " +
+			"-XX:+UseSerialGC " +
+			"MainKt",
+		SeccompRule:           "",
+		MemoeryLimitCheckOnly: false,
+		TimeScaling:           jvmTimeScaling,
+	}
+
+	var nodeConfig = JudgerConfig{
+		Language:              sandbox.JAVASCRIPT,
+		SrcName:               "main.js",
+		ExeName:               "main.js",
+		MaxCompileCpuTime:     3000,
+		MaxCompileRealTime:    10000,
+		MaxCompileMemory:      1024 * 1024 * 1024,
+		CompilerPath:          "/usr/bin/node",
+		CompileArgs:           "--check {srcPath}",
+		RunCommand:            "/usr/bin/node",
+		RunArgs:               "--stack-size=65500 {exePath}",
+		SeccompRule:           "node",
+		MemoeryLimitCheckOnly: true,
+	}
+
+	return []JudgerConfig{
+		cConfig,
+		cppConfig(sandbox.CPP14, "c++14"),
+		cppConfig(sandbox.CPP17, "c++17"),
+		cppConfig(sandbox.CPP20, "c++20"),
+		javaConfig(sandbox.JAVA17, "JAVA17_PATH"),
+		javaConfig(sandbox.JAVA21, "JAVA21_PATH"),
+		pyConfig(sandbox.PYTHON311, "311"),
+		pyConfig(sandbox.PYTHON312, "312"),
+		pypyConfig,
+		goConfig,
+		rustConfig,
+		kotlinConfig,
+		nodeConfig,
 	}
 }
 
+// GetConfig looks up language as given first, so that a language config file
+// may still define an alias such as "Cpp" on its own, then as the version the
+// alias stands for.
 func (l *langConfig) GetConfig(language sandbox.Language) (JudgerConfig, error) {
-	switch language {
-	case sandbox.C:
-		return l.cConfig, nil
-	case sandbox.CPP:
-		return l.cppConfig, nil
-	case sandbox.JAVA:
-		return l.javaConfig, nil
-	case sandbox.PYTHON:
-		return l.pyConfig, nil
-	case sandbox.PYPY:
-		return l.pypyConfig, nil
+	c, ok := l.configs[language]
+	if !ok {
+		c, ok = l.configs[language.Resolve()]
 	}
-	return JudgerConfig{}, fmt.Errorf("unsupported language: %s", language)
+	if !ok {
+		return JudgerConfig{}, fmt.Errorf("unsupported language: %s", language)
+	}
+	return c, nil
 }
 
 func (l *langConfig) MakeSrcPath(dir string, language sandbox.Language) (string, error) {
@@ -175,18 +288,6 @@ func (l *langConfig) MakeSrcPath(dir string, language sandbox.Language) (string,
 	return l.file.MakeFilePath(dir, c.SrcName).String(), nil
 }
 
-func GetPythonVersion() (string, error) {
-	cmd := exec.Command("python3", "--version")
-	output, err := cmd.Output()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	versionParts := strings.Split(string(output), " ")
-	version := strings.Split(versionParts[1], ".")
-	return version[0] + version[1], nil
-}
-
 func (l *langConfig) ToCompileExecArgs(dir string, language sandbox.Language) (ExecArgs, error) {
 	c, err := l.GetConfig(language)
 	if err != nil {
@@ -219,6 +320,9 @@ func (l *langConfig) ToCompileExecArgs(dir string, language sandbox.Language) (E
 		ErrorPath:     outputPath,
 		LogPath:       constants.COMPILE_LOG_PATH,
 		Args:          argSlice,
+		Env:           expandEnv(c.CompileEnv, exeDir),
+		// 컴파일러는 build 디렉토리에 실행 파일을 씀
+		FileIo: true,
 	}, nil
 }
 
@@ -246,26 +350,48 @@ func (l *langConfig) ToRunExecArgs(dir string, language sandbox.Language, order
 		argSlice = append(argSlice, extraArgs...)
 	}
 
+	seccompRule := c.SeccompRule
+	if fileIo && c.SeccompRuleFileIO != "" {
+		seccompRule = c.SeccompRuleFileIO
+	}
+
 	maxMemory := limit.Memory
 	// if c.Language == sandbox.JAVA {
 	// 	maxMemory = -1
 	// }
 
+	maxOutputSize := limit.Output
+	if maxOutputSize <= 0 {
+		maxOutputSize = sandbox.DefaultOutputLimit
+	}
+
 	return ExecArgs{
-		ExePath:      strings.Replace(c.RunCommand, "{exePath}", exePath, 1),
-		MaxCpuTime:   limit.CpuTime,
-		MaxRealTime:  limit.RealTime,
-		MaxMemory:    maxMemory,
-		MaxStackSize: 128 * 1024 * 1024,
-		// FIXME: testcase크기 따라서 설정하거나, 그냥 바로 stdout 읽어오거나 -> 이러면 order 필요없음
-		MaxOutputSize: 10 * 1024 * 1024,
+		ExePath:       strings.Replace(c.RunCommand, "{exePath}", exePath, 1),
+		MaxCpuTime:    limit.CpuTime,
+		MaxRealTime:   limit.RealTime,
+		MaxMemory:     maxMemory,
+		MaxStackSize:  128 * 1024 * 1024,
+		MaxOutputSize: maxOutputSize,
 		// file에 쓰는거랑 stdout이랑 크게 차이 안남
 		// https://stackoverflow.com/questions/29700478/redirecting-of-stdout-in-bash-vs-writing-to-file-in-c-with-fprintf-speed
 		OutputPath:           outputPath,
 		ErrorPath:            errorPath, // byte buffer로
 		LogPath:              constants.RUN_LOG_PATH,
-		SeccompRuleName:      c.SeccompRule,
+		SeccompRuleName:      seccompRule,
 		MemoryLimitCheckOnly: c.MemoeryLimitCheckOnly,
 		Args:                 argSlice,
+		Env:                  expandEnv(c.Env, exeDir),
+		FileIo:               fileIo,
 	}, nil
 }
+
+func expandEnv(env []string, exeDir string) []string {
+	if env == nil {
+		return nil
+	}
+	expanded := make([]string, len(env))
+	for i, e := range env {
+		expanded[i] = strings.Replace(e, "{exeDir}", exeDir, 1)
+	}
+	return expanded
+}
@@ -0,0 +1,166 @@
+package judger
+
+import (
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToCompileExecArgs(t *testing.T) {
+	t.Setenv("KOTLIN_PATH", "/opt/kotlinc/bin")
+	langConfig := NewJudgerLangConfig(file.NewFileManager("/sandbox"), "/policy")
+
+	tests := []struct {
+		language sandbox.Language
+		exePath  string
+		args     []string
+		env      []string
+	}{
+		{
+			language: sandbox.GO,
+			exePath:  "/usr/bin/go",
+			args:     []string{"build", "-o", "/sandbox/unit/main", "/sandbox/unit/main.go"},
+			env:      []string{"LANG=en_US.UTF-8", "LANGUAGE=en_US:en", "LC_ALL=en_US.UTF-8", "GOCACHE=/tmp/go-cache", "GOPATH=/tmp/go"},
+		},
+		{
+			language: sandbox.RUST,
+			exePath:  "/usr/bin/rustc",
+			args:     []string{"--edition", "2021", "-O", "--cfg", "online_judge", "-o", "/sandbox/unit/main", "/sandbox/unit/main.rs"},
+		},
+		{
+			language: sandbox.KOTLIN,
+			exePath:  "/opt/kotlinc/bin/kotlinc",
+			args:     []string{"/sandbox/unit/Main.kt", "-include-runtime", "-d", "/sandbox/unit/Main.jar"},
+		},
+		{
+			language: sandbox.JAVASCRIPT,
+			exePath:  "/usr/bin/node",
+			args:     []string{"--check", "/sandbox/unit/main.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.language), func(t *testing.T) {
+			assert.True(t, tt.language.IsValid())
+
+			args, err := langConfig.ToCompileExecArgs("unit", tt.language)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.exePath, args.ExePath)
+			assert.Equal(t, tt.args, args.Args)
+			assert.Equal(t, tt.env, args.Env)
+			assert.Equal(t, "/sandbox/unit/compile.out", args.OutputPath)
+			assert.True(t, args.FileIo, "compilers write the executable")
+		})
+	}
+}
+
+func TestToRunExecArgs(t *testing.T) {
+	t.Setenv("JAVA_PATH", "/usr/lib/jvm/bin")
+	langConfig := NewJudgerLangConfig(file.NewFileManager("/sandbox"), "/policy")
+	limit := sandbox.Limit{CpuTime: 1000, RealTime: 3000, Memory: 256 * 1024 * 1024}
+
+	tests := []struct {
+		language        sandbox.Language
+		exePath         string
+		args            []string
+		seccompRule     string
+		checkMemoryOnly bool
+	}{
+		{
+			language:        sandbox.GO,
+			exePath:         "/sandbox/unit/main",
+			seccompRule:     "golang",
+			checkMemoryOnly: true,
+		},
+		{
+			language:    sandbox.RUST,
+			exePath:     "/sandbox/unit/main",
+			seccompRule: "general",
+		},
+		{
+			language: sandbox.KOTLIN,
+			exePath:  "/usr/lib/jvm/bin/java",
+			args: []string{
+				"-cp", "/sandbox/unit/Main.jar",
+				"-Djava.security.manager",
+				"-Dfile.encoding=UTF-8",
+				"-Djava.security.policy==/policy",
+				"-Djava.awt.headless=true",
+				"-XX:+UseSerialGC",
+				"MainKt",
+			},
+		},
+		{
+			language:        sandbox.JAVASCRIPT,
+			exePath:         "/usr/bin/node",
+			args:            []string{"--stack-size=65500", "/sandbox/unit/main.js"},
+			seccompRule:     "node",
+			checkMemoryOnly: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.language), func(t *testing.T) {
+			args, err := langConfig.ToRunExecArgs("unit", tt.language, 3, limit, false, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.exePath, args.ExePath)
+			assert.Equal(t, tt.args, args.Args)
+			assert.Equal(t, tt.seccompRule, args.SeccompRuleName)
+			assert.Equal(t, tt.checkMemoryOnly, args.MemoryLimitCheckOnly)
+			assert.Equal(t, "/sandbox/unit/3.out", args.OutputPath)
+			assert.Equal(t, "/sandbox/unit/3.error", args.ErrorPath)
+			assert.Equal(t, limit.Memory, args.MaxMemory)
+		})
+	}
+
+	t.Run("built-in languages run with PATH only", func(t *testing.T) {
+		for _, config := range DefaultJudgerConfigs("/policy") {
+			args, err := langConfig.ToRunExecArgs("unit", config.Language, 0, limit, false, nil)
+			require.NoError(t, err)
+			assert.Nil(t, args.Env, config.Language)
+		}
+	})
+}
+
+func TestVersionedLanguages(t *testing.T) {
+	t.Setenv("JAVA_PATH", "/usr/bin")
+	t.Setenv("JAVA21_PATH", "/usr/lib/jvm/java-21/bin")
+	langConfig := NewJudgerLangConfig(file.NewFileManager("/sandbox"), "/policy")
+
+	tests := []struct {
+		language sandbox.Language
+		exePath  string
+		arg      string
+	}{
+		{language: sandbox.CPP, exePath: "/usr/bin/g++", arg: "-std=c++14"},
+		{language: sandbox.CPP17, exePath: "/usr/bin/g++", arg: "-std=c++17"},
+		{language: sandbox.CPP20, exePath: "/usr/bin/g++", arg: "-std=c++20"},
+		{language: sandbox.JAVA, exePath: "/usr/bin/javac", arg: "-encoding"},
+		{language: sandbox.JAVA21, exePath: "/usr/lib/jvm/java-21/bin/javac", arg: "-encoding"},
+		{language: sandbox.PYTHON, exePath: "/usr/bin/python3.12", arg: "py_compile"},
+		{language: sandbox.PYTHON311, exePath: "/usr/bin/python3.11", arg: "py_compile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.language), func(t *testing.T) {
+			assert.True(t, tt.language.IsValid())
+
+			args, err := langConfig.ToCompileExecArgs("unit", tt.language)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.exePath, args.ExePath)
+			assert.Contains(t, args.Args, tt.arg)
+		})
+	}
+
+	t.Run("names the bytecode after the python version", func(t *testing.T) {
+		config, err := langConfig.GetConfig(sandbox.PYTHON311)
+		require.NoError(t, err)
+		assert.Equal(t, "__pycache__/solution.cpython-311.pyc", config.ExeName)
+	})
+}
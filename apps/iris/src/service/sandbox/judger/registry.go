@@ -0,0 +1,79 @@
+package judger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LanguageConfigPathEnv points to a YAML or JSON file of language configs
+// that extend or override the built-in set, e.g.
+//
+//	languages:
+//	  - language: Ruby
+//	    srcName: main.rb
+//	    exeName: main.rb
+//	    timeScaling: { scale: 2, realTimeGrace: 500 }
+//	    ...
+const LanguageConfigPathEnv = "LANGUAGE_CONFIG_PATH"
+
+type languageRegistry struct {
+	Languages []JudgerConfig `yaml:"languages"`
+}
+
+// LoadJudgerConfigs reads and validates the language configs in path.
+// JSON is accepted as well since it is a subset of YAML.
+func LoadJudgerConfigs(path string) ([]JudgerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading language config: %w", err)
+	}
+	return ParseJudgerConfigs(data)
+}
+
+func ParseJudgerConfigs(data []byte) ([]JudgerConfig, error) {
+	var registry languageRegistry
+	if err := yaml.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("parsing language config: %w", err)
+	}
+
+	seen := make(map[string]bool, len(registry.Languages))
+	for idx, c := range registry.Languages {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("languages[%d]: %w", idx, err)
+		}
+		if seen[string(c.Language)] {
+			return nil, fmt.Errorf("languages[%d]: duplicate language: %s", idx, c.Language)
+		}
+		seen[string(c.Language)] = true
+	}
+	return registry.Languages, nil
+}
+
+func (c JudgerConfig) Validate() error {
+	switch {
+	case c.Language == "":
+		return errors.New("language must not be empty")
+	case c.SrcName == "":
+		return errors.New("srcName must not be empty")
+	case c.ExeName == "":
+		return errors.New("exeName must not be empty")
+	case c.CompilerPath == "":
+		return errors.New("compilerPath must not be empty")
+	case c.RunCommand == "":
+		return errors.New("runCommand must not be empty")
+	case c.MaxCompileCpuTime <= 0:
+		return errors.New("maxCompileCpuTime must be greater than 0")
+	case c.MaxCompileRealTime <= 0:
+		return errors.New("maxCompileRealTime must be greater than 0")
+	case c.MaxCompileMemory <= 0 && c.MaxCompileMemory != -1:
+		// -1 leaves the compiler unlimited, e.g. for the JVM
+		return errors.New("maxCompileMemory must be greater than 0 or -1")
+	}
+	if err := c.TimeScaling.Validate(); err != nil {
+		return fmt.Errorf("timeScaling: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package judger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rubyConfig = `
+languages:
+  - language: Ruby
+    srcName: main.rb
+    exeName: main.rb
+    maxCompileCpuTime: 3000
+    maxCompileRealTime: 10000
+    maxCompileMemory: 134217728
+    compilerPath: /usr/bin/ruby
+    compileArgs: "-c {srcPath}"
+    runCommand: /usr/bin/ruby
+    runArgs: "{exePath}"
+    memoryLimitCheckOnly: true
+    env: ["RUBYLIB={exeDir}"]
+    timeScaling:
+      scale: 2
+      realTimeGrace: 500
+  - language: Cpp
+    srcName: main.cpp
+    exeName: main
+    maxCompileCpuTime: 10000
+    maxCompileRealTime: 20000
+    maxCompileMemory: -1
+    compilerPath: /usr/bin/clang++
+    compileArgs: "-std=c++20 {srcPath} -o {exePath}"
+    runCommand: "{exePath}"
+    seccompRule: c_cpp
+`
+
+func TestParseJudgerConfigs(t *testing.T) {
+	t.Run("parses yaml", func(t *testing.T) {
+		configs, err := ParseJudgerConfigs([]byte(rubyConfig))
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+		assert.Equal(t, sandbox.Language("Ruby"), configs[0].Language)
+		assert.True(t, configs[0].MemoeryLimitCheckOnly)
+		assert.Equal(t, []string{"RUBYLIB={exeDir}"}, configs[0].Env)
+		assert.Equal(t, sandbox.TimeScaling{Scale: 2, RealTimeGrace: 500}, configs[0].TimeScaling)
+	})
+
+	t.Run("parses json", func(t *testing.T) {
+		configs, err := ParseJudgerConfigs([]byte(`{"languages": [{
+			"language": "Ruby", "srcName": "main.rb", "exeName": "main.rb",
+			"maxCompileCpuTime": 1, "maxCompileRealTime": 1, "maxCompileMemory": 1,
+			"compilerPath": "/usr/bin/ruby", "runCommand": "/usr/bin/ruby"
+		}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/bin/ruby", configs[0].CompilerPath)
+	})
+
+	t.Run("rejects invalid entries", func(t *testing.T) {
+		tests := map[string]string{
+			"missing language":  `{"languages": [{"srcName": "a"}]}`,
+			"missing runner":    `{"languages": [{"language": "A", "srcName": "a", "exeName": "a", "compilerPath": "a", "maxCompileCpuTime": 1, "maxCompileRealTime": 1, "maxCompileMemory": 1}]}`,
+			"zero compile time": `{"languages": [{"language": "A", "srcName": "a", "exeName": "a", "compilerPath": "a", "runCommand": "a", "maxCompileRealTime": 1, "maxCompileMemory": 1}]}`,
+			"duplicate":         `{"languages": [{"language": "A", "srcName": "a", "exeName": "a", "compilerPath": "a", "runCommand": "a", "maxCompileCpuTime": 1, "maxCompileRealTime": 1, "maxCompileMemory": 1}, {"language": "A", "srcName": "a", "exeName": "a", "compilerPath": "a", "runCommand": "a", "maxCompileCpuTime": 1, "maxCompileRealTime": 1, "maxCompileMemory": 1}]}`,
+			"negative scale":    `{"languages": [{"language": "A", "srcName": "a", "exeName": "a", "compilerPath": "a", "runCommand": "a", "maxCompileCpuTime": 1, "maxCompileRealTime": 1, "maxCompileMemory": 1, "timeScaling": {"scale": -1}}]}`,
+			"malformed":         `languages: [`,
+		}
+		for name, raw := range tests {
+			_, err := ParseJudgerConfigs([]byte(raw))
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestNewJudgerLangConfigFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "languages.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rubyConfig), 0644))
+
+	langConfig, err := NewJudgerLangConfigFromFile(file.NewFileManager("/sandbox"), "/policy", path)
+	require.NoError(t, err)
+
+	t.Run("adds new languages", func(t *testing.T) {
+		assert.True(t, sandbox.Language("Ruby").IsValid())
+
+		args, err := langConfig.ToRunExecArgs("unit", "Ruby", 0, sandbox.Limit{CpuTime: 1, RealTime: 1, Memory: 1}, false, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/bin/ruby", args.ExePath)
+		assert.Equal(t, []string{"/sandbox/unit/main.rb"}, args.Args)
+		assert.Equal(t, []string{"RUBYLIB=/sandbox/unit/"}, args.Env)
+	})
+
+	t.Run("overrides built-in languages", func(t *testing.T) {
+		config, err := langConfig.GetConfig(sandbox.CPP)
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/bin/clang++", config.CompilerPath)
+	})
+
+	t.Run("keeps other built-in languages", func(t *testing.T) {
+		config, err := langConfig.GetConfig(sandbox.C)
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/bin/gcc", config.CompilerPath)
+	})
+
+	t.Run("fails on missing file", func(t *testing.T) {
+		_, err := NewJudgerLangConfigFromFile(nil, "/policy", filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
@@ -2,8 +2,12 @@ package judger
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"sync"
 
+	"github.com/skkuding/codedang/apps/iris/src/common/constants"
 	"github.com/skkuding/codedang/apps/iris/src/service/file"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
@@ -22,21 +26,11 @@ func NewJudgerRunner(judgerExec JudgerExec, langConfig sandbox.LangConfig[Judger
 
 func (r *runner) Run(req sandbox.RunRequest, input []byte) (sandbox.RunResult, error) {
 
-	execArgs, err := r.langConfig.ToRunExecArgs(
-		req.Dir,
-		req.Language,
-		req.Order,
-		sandbox.Limit{
-			CpuTime:  req.TimeLimit,
-			RealTime: req.TimeLimit * 3,
-			Memory:   req.MemoryLimit,
-		},
-		false,
-		req.ExtraArgs,
-	)
+	execArgs, err := r.toExecArgs(req, false)
 	if err != nil {
 		return sandbox.RunResult{}, err
 	}
+	execArgs.InputPath = req.InputPath
 
 	execResult, err := r.judgerExec.Exec(execArgs, input)
 	if err != nil {
@@ -45,27 +39,170 @@ func (r *runner) Run(req sandbox.RunRequest, input []byte) (sandbox.RunResult, e
 
 	runResult := sandbox.RunResult{
 		Order:      req.Order,
+		OutputPath: execArgs.OutputPath,
 		ExecResult: execResult,
 	}
 
 	if execResult.ErrorCode != SUCCESS {
 		return runResult, fmt.Errorf("execution failed (error code %d)", execResult.ErrorCode)
 	}
+	if execResult.StatusCode == sandbox.RUNTIME_ERROR && outputLimitReached(execArgs) {
+		runResult.ExecResult.StatusCode = sandbox.OUTPUT_LIMIT_EXCEEDED
+	}
+	// libjudger reports the real time limit when both limits are exceeded; a
+	// run that used up its CPU time is slow, not idle
+	if execResult.StatusCode == sandbox.REAL_TIME_LIMIT_EXCEEDED && execResult.CpuTime > execArgs.MaxCpuTime {
+		runResult.ExecResult.StatusCode = sandbox.CPU_TIME_LIMIT_EXCEEDED
+	}
 
-	orderStr := strconv.Itoa(req.Order)
-	if ResultCode(execResult.StatusCode) != RUN_SUCCESS {
-		errorPath := r.file.MakeFilePath(req.Dir, orderStr+".error").String()
-		errData, err := r.file.ReadFile(errorPath)
-		if err != nil {
-			return runResult, fmt.Errorf("reading error output file: %w", err)
-		}
-		runResult.ErrOutput = errData
+	if err := r.readErrOutput(req, &runResult); err != nil {
+		return runResult, err
+	}
+	if req.OutputOnDisk {
+		return runResult, nil
 	}
-	outputPath := r.file.MakeFilePath(req.Dir, orderStr+".out").String()
-	outputData, err := r.file.ReadFile(outputPath)
+	outputData, err := r.file.ReadFile(execArgs.OutputPath)
 	if err != nil {
 		return runResult, fmt.Errorf("reading output file: %w", err)
 	}
 	runResult.Output = outputData
 	return runResult, nil
 }
+
+// RunInteractive runs the solution and the interactor concurrently, each in
+// its own sandbox, with the stdout of one connected to the stdin of the other.
+// The interactor may write files (e.g. testlib's result file), so it runs
+// under the file IO seccomp rule of its language.
+func (r *runner) RunInteractive(solution sandbox.RunRequest, interactor sandbox.RunRequest) (sandbox.InteractiveRunResult, error) {
+	solutionArgs, err := r.toExecArgs(solution, false)
+	if err != nil {
+		return sandbox.InteractiveRunResult{}, err
+	}
+	interactorArgs, err := r.toExecArgs(interactor, true)
+	if err != nil {
+		return sandbox.InteractiveRunResult{}, err
+	}
+
+	// solution -> interactor
+	toInteractorR, toInteractorW, err := os.Pipe()
+	if err != nil {
+		return sandbox.InteractiveRunResult{}, fmt.Errorf("creating pipe: %w", err)
+	}
+	// interactor -> solution
+	toSolutionR, toSolutionW, err := os.Pipe()
+	if err != nil {
+		toInteractorR.Close()
+		toInteractorW.Close()
+		return sandbox.InteractiveRunResult{}, fmt.Errorf("creating pipe: %w", err)
+	}
+
+	var solutionResult, interactorResult sandbox.ExecResult
+	var solutionErr, interactorErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		solutionResult, solutionErr = r.judgerExec.ExecPiped(solutionArgs, toSolutionR, toInteractorW)
+	}()
+	go func() {
+		defer wg.Done()
+		interactorResult, interactorErr = r.judgerExec.ExecPiped(interactorArgs, toInteractorR, toSolutionW)
+	}()
+	wg.Wait()
+
+	if solutionErr != nil {
+		return sandbox.InteractiveRunResult{}, fmt.Errorf("solution execution failed: %w", solutionErr)
+	}
+	if interactorErr != nil {
+		return sandbox.InteractiveRunResult{}, fmt.Errorf("interactor execution failed: %w", interactorErr)
+	}
+
+	result := sandbox.InteractiveRunResult{
+		Solution:   sandbox.RunResult{Order: solution.Order, ExecResult: solutionResult},
+		Interactor: sandbox.RunResult{Order: interactor.Order, ExecResult: interactorResult},
+	}
+	if solutionResult.ErrorCode != SUCCESS {
+		return result, fmt.Errorf("solution execution failed (error code %d)", solutionResult.ErrorCode)
+	}
+	if interactorResult.ErrorCode != SUCCESS {
+		return result, fmt.Errorf("interactor execution failed (error code %d)", interactorResult.ErrorCode)
+	}
+
+	if err := r.readErrOutput(solution, &result.Solution); err != nil {
+		return result, err
+	}
+	if err := r.readErrOutput(interactor, &result.Interactor); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (r *runner) toExecArgs(req sandbox.RunRequest, fileIo bool) (ExecArgs, error) {
+	config, err := r.langConfig.GetConfig(req.Language)
+	if err != nil {
+		return ExecArgs{}, err
+	}
+	cpuTime, realTime := config.TimeScaling.Override(req.TimeScaling).Limits(req.TimeLimit)
+
+	args, err := r.langConfig.ToRunExecArgs(
+		req.Dir,
+		req.Language,
+		req.Order,
+		sandbox.Limit{
+			CpuTime:  cpuTime,
+			RealTime: realTime,
+			Memory:   req.MemoryLimit,
+			Output:   req.OutputLimit,
+		},
+		fileIo,
+		req.ExtraArgs,
+	)
+	if err != nil {
+		return ExecArgs{}, err
+	}
+	args.OutputPath = r.artifactPath(req, ".out")
+	args.ErrorPath = r.artifactPath(req, ".error")
+	args.CpuSet = req.CpuSet
+	return args, nil
+}
+
+// outputLimitReached reports whether the stdout or stderr file of a run grew
+// to its size limit. Processes that ignore SIGXFSZ (e.g. Python) fail to
+// write past the limit and exit with an error instead of being killed.
+func outputLimitReached(args ExecArgs) bool {
+	if args.MaxOutputSize <= 0 {
+		return false
+	}
+	for _, path := range []string{args.OutputPath, args.ErrorPath} {
+		if info, err := os.Stat(path); err == nil && info.Size() >= int64(args.MaxOutputSize) {
+			return true
+		}
+	}
+	return false
+}
+
+// artifactPath returns where the run of req leaves its stdout/stderr file.
+func (r *runner) artifactPath(req sandbox.RunRequest, ext string) string {
+	dir := req.Dir
+	if req.OutputDir != "" {
+		dir = req.OutputDir
+	}
+	return r.file.MakeFilePath(dir, strconv.Itoa(req.Order)+ext).String()
+}
+
+// readErrOutput reads stderr even on success because testlib tools
+// (checkers, validators, interactors) report their verdict message there.
+// Only the first constants.MAX_STDERR bytes are read.
+func (r *runner) readErrOutput(req sandbox.RunRequest, runResult *sandbox.RunResult) error {
+	errFile, err := os.Open(r.artifactPath(req, ".error"))
+	if err != nil {
+		return fmt.Errorf("reading error output file: %w", err)
+	}
+	defer errFile.Close()
+	errData, err := io.ReadAll(io.LimitReader(errFile, constants.MAX_STDERR))
+	if err != nil {
+		return fmt.Errorf("reading error output file: %w", err)
+	}
+	runResult.ErrOutput = errData
+	return nil
+}
@@ -0,0 +1,96 @@
+package judger
+
+import (
+	"os"
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/service/file"
+	"github.com/skkuding/codedang/apps/iris/src/service/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubExec records the arguments of the last run and answers with result,
+// leaving empty stdout/stderr files behind like libjudger does.
+type stubExec struct {
+	args   ExecArgs
+	result sandbox.ExecResult
+}
+
+func (s *stubExec) Exec(args ExecArgs, _ []byte) (sandbox.ExecResult, error) {
+	s.args = args
+	for _, path := range []string{args.OutputPath, args.ErrorPath} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return sandbox.ExecResult{}, err
+		}
+	}
+	return s.result, nil
+}
+
+func (s *stubExec) ExecPiped(args ExecArgs, _ *os.File, _ *os.File) (sandbox.ExecResult, error) {
+	return s.Exec(args, nil)
+}
+
+func newStubRunner(t *testing.T, result sandbox.ExecResult) (*runner, *stubExec) {
+	t.Setenv("JAVA_PATH", "/usr/lib/jvm/java/bin")
+	baseDir := t.TempDir()
+	require.NoError(t, os.Mkdir(baseDir+"/unit", 0o755))
+	fileManager := file.NewFileManager(baseDir)
+	exec := &stubExec{result: result}
+	return NewJudgerRunner(exec, NewJudgerLangConfig(fileManager, "/policy"), fileManager, noopLogger{}), exec
+}
+
+func TestRunTimeLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      sandbox.RunRequest
+		cpuTime  int
+		realTime int
+	}{
+		{"default", sandbox.RunRequest{Language: sandbox.C, TimeLimit: 1000}, 1000, 3000},
+		{"language grace", sandbox.RunRequest{Language: sandbox.JAVA, TimeLimit: 1000}, 1000, 4000},
+		{
+			"request overrides",
+			sandbox.RunRequest{Language: sandbox.JAVA, TimeLimit: 1000, TimeScaling: sandbox.TimeScaling{Scale: 1.5, RealTimeMultiplier: 2}},
+			1500, 4000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, exec := newStubRunner(t, sandbox.ExecResult{})
+			tt.req.Dir = "unit"
+
+			_, err := r.Run(tt.req, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.cpuTime, exec.args.MaxCpuTime)
+			assert.Equal(t, tt.realTime, exec.args.MaxRealTime)
+		})
+	}
+}
+
+func TestRunSeparatesTimeVerdicts(t *testing.T) {
+	req := sandbox.RunRequest{Dir: "unit", Language: sandbox.C, TimeLimit: 1000}
+
+	t.Run("idle run stays real time limit exceeded", func(t *testing.T) {
+		r, _ := newStubRunner(t, sandbox.ExecResult{StatusCode: sandbox.REAL_TIME_LIMIT_EXCEEDED, CpuTime: 10, RealTime: 3001})
+		result, err := r.Run(req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.REAL_TIME_LIMIT_EXCEEDED, result.ExecResult.StatusCode)
+	})
+
+	t.Run("run within its cpu time stays real time limit exceeded", func(t *testing.T) {
+		r, _ := newStubRunner(t, sandbox.ExecResult{StatusCode: sandbox.REAL_TIME_LIMIT_EXCEEDED, CpuTime: 900, RealTime: 3001})
+		result, err := r.Run(req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.REAL_TIME_LIMIT_EXCEEDED, result.ExecResult.StatusCode)
+	})
+
+	t.Run("run over both limits is cpu time limit exceeded", func(t *testing.T) {
+		r, _ := newStubRunner(t, sandbox.ExecResult{StatusCode: sandbox.REAL_TIME_LIMIT_EXCEEDED, CpuTime: 1200, RealTime: 3001})
+		result, err := r.Run(req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sandbox.CPU_TIME_LIMIT_EXCEEDED, result.ExecResult.StatusCode)
+	})
+}
@@ -1,21 +1,65 @@
 package sandbox
 
+import "sync"
+
 type Language string
 
+var (
+	languagesMu sync.RWMutex
+	languages   = map[Language]bool{
+		C: true, CPP14: true, CPP17: true, CPP20: true, JAVA17: true, JAVA21: true,
+		PYTHON311: true, PYTHON312: true, PYPY: true,
+		GO: true, RUST: true, KOTLIN: true, JAVASCRIPT: true,
+	}
+
+	// aliases keeps the identifiers from before languages were versioned
+	// working. Each stands for the version it used to run on.
+	aliases = map[Language]Language{
+		CPP:    CPP14,
+		JAVA:   JAVA17,
+		PYTHON: PYTHON312,
+	}
+)
+
+// RegisterLanguage marks a language defined outside the built-in set, e.g. by
+// a language config file, as valid.
+func RegisterLanguage(l Language) {
+	languagesMu.Lock()
+	defer languagesMu.Unlock()
+	languages[l] = true
+}
+
 func (l Language) IsValid() bool {
-	switch l {
-	case "C", "Cpp", "Java", "Python3", "PyPy3":
-		return true
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	return languages[l] || languages[l.Resolve()]
+}
+
+// Resolve returns the versioned language an alias stands for, or l itself.
+func (l Language) Resolve() Language {
+	if version, ok := aliases[l]; ok {
+		return version
 	}
-	return false
+	return l
 }
 
 const (
-	C      Language = "C"
-	CPP    Language = "Cpp"
-	JAVA   Language = "Java"
-	PYTHON Language = "Python3"
-	PYPY   Language = "PyPy3"
+	C          Language = "C"
+	CPP        Language = "Cpp"
+	CPP14      Language = "Cpp14"
+	CPP17      Language = "Cpp17"
+	CPP20      Language = "Cpp20"
+	JAVA       Language = "Java"
+	JAVA17     Language = "Java17"
+	JAVA21     Language = "Java21"
+	PYTHON     Language = "Python3"
+	PYTHON311  Language = "Python3.11"
+	PYTHON312  Language = "Python3.12"
+	PYPY       Language = "PyPy3"
+	GO         Language = "Golang"
+	RUST       Language = "Rust"
+	KOTLIN     Language = "Kotlin"
+	JAVASCRIPT Language = "JavaScript"
 )
 
 type LangConfig[C any, E any] interface {
@@ -25,8 +69,14 @@ type LangConfig[C any, E any] interface {
 	ToRunExecArgs(dir string, language Language, order int, limit Limit, fileIo bool, extraArgs []string) (E, error)
 }
 
+// DefaultOutputLimit bounds the stdout/stderr files of a run whose Limit
+// leaves Output unset.
+const DefaultOutputLimit = 10 * 1024 * 1024
+
 type Limit struct {
 	CpuTime  int
 	RealTime int
 	Memory   int
+	// Output is the size of stdout and of stderr in bytes
+	Output int
 }
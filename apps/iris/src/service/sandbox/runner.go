@@ -4,9 +4,11 @@ type Runner interface {
 	Run(req RunRequest, input []byte) (RunResult, error)
 }
 type RunResult struct {
-	Order      int
-	ErrOutput  []byte
-	Output     []byte
+	Order     int
+	ErrOutput []byte
+	Output    []byte
+	// OutputPath is the file the output was written to
+	OutputPath string
 	ExecResult ExecResult
 }
 
@@ -17,4 +19,17 @@ type RunRequest struct {
 	TimeLimit   int
 	MemoryLimit int
 	ExtraArgs   []string
+	// OutputDir receives the stdout/stderr files instead of Dir when set
+	OutputDir string
+	// CpuSet pins the run to the given CPUs when set
+	CpuSet []int
+	// InputPath is read as stdin instead of the input passed to Run when set
+	InputPath string
+	// OutputOnDisk leaves the output in the file at RunResult.OutputPath
+	// instead of reading it into RunResult.Output
+	OutputOnDisk bool
+	// OutputLimit bounds stdout and stderr in bytes, DefaultOutputLimit if 0
+	OutputLimit int
+	// TimeScaling overrides the time scaling of the language field by field
+	TimeScaling TimeScaling
 }
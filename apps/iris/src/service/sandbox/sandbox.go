@@ -3,6 +3,7 @@ package sandbox
 type Sandbox[C any, E any] interface {
 	Compiler
 	Runner
+	Interactor
 	LangConfig[C, E]
 }
 
@@ -16,3 +17,12 @@ type ExecResult struct {
 	StatusCode StatusCode `json:"result"`
 	CgroupPath string     `json:"cgroup_path"`
 }
+
+// Backend is the implementation processes are sandboxed with, selected at
+// startup with IRIS_SANDBOX.
+type Backend string
+
+const (
+	JUDGER  Backend = "Judger"
+	ISOLATE Backend = "Isolate"
+)
@@ -0,0 +1,70 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// SignalName returns the name of the signal a process was killed by, e.g.
+// "SIGSEGV", or "" if it was not killed by a known signal.
+func SignalName(signal int) string {
+	if signal <= 0 {
+		return ""
+	}
+	return unix.SignalName(syscall.Signal(signal))
+}
+
+// runtimeErrorPatterns recognize the errors language runtimes report on
+// stderr before exiting with a non-zero code, in order of precedence.
+var runtimeErrorPatterns = []struct {
+	patterns []string
+	reason   string
+}{
+	{[]string{"StackOverflowError", "RecursionError", "stack overflow", "overflowed its stack", "goroutine stack exceeds"}, "stack overflow"},
+	{[]string{"ZeroDivisionError", "/ by zero", "divide by zero", "division by zero"}, "division by zero"},
+	{[]string{"OutOfMemoryError", "MemoryError", "out of memory"}, "out of memory"},
+	{[]string{"IndexOutOfBounds", "IndexError", "index out of range", "index out of bounds"}, "index out of range"},
+	{[]string{"NullPointerException", "nil pointer dereference", "NoneType"}, "null reference"},
+	{[]string{"NumberFormatException", "ValueError", "InputMismatchException", "NoSuchElementException", "EOFError"}, "invalid input"},
+}
+
+// RuntimeErrorReason explains in words why a run ended with a runtime error,
+// from the error the language runtime printed to stderr or else the signal
+// or exit code. It returns "" for runs that did not end with one.
+func RuntimeErrorReason(result ExecResult, stderr []byte) string {
+	if result.StatusCode != RUNTIME_ERROR && result.StatusCode != SEGMENTATION_FAULT_ERROR {
+		return ""
+	}
+
+	for _, e := range runtimeErrorPatterns {
+		for _, pattern := range e.patterns {
+			if bytes.Contains(stderr, []byte(pattern)) {
+				return e.reason
+			}
+		}
+	}
+
+	switch syscall.Signal(result.Signal) {
+	case 0:
+	case syscall.SIGSEGV:
+		return "segmentation fault (invalid memory access or stack overflow)"
+	case syscall.SIGFPE:
+		return "division by zero"
+	case syscall.SIGABRT:
+		return "aborted (e.g. failed assertion or uncaught exception)"
+	case syscall.SIGBUS:
+		return "bus error (misaligned memory access)"
+	case syscall.SIGKILL:
+		return "killed"
+	default:
+		return fmt.Sprintf("killed by %s", SignalName(result.Signal))
+	}
+
+	if result.ExitCode != 0 {
+		return fmt.Sprintf("exited with code %d", result.ExitCode)
+	}
+	return ""
+}
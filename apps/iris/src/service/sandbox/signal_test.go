@@ -0,0 +1,41 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalName(t *testing.T) {
+	assert.Equal(t, "SIGSEGV", SignalName(11))
+	assert.Equal(t, "SIGFPE", SignalName(8))
+	assert.Equal(t, "SIGABRT", SignalName(6))
+	assert.Equal(t, "", SignalName(0))
+}
+
+func TestRuntimeErrorReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ExecResult
+		stderr string
+		want   string
+	}{
+		{"accepted", ExecResult{StatusCode: RUN_SUCCESS}, "debug output", ""},
+		{"time limit", ExecResult{StatusCode: CPU_TIME_LIMIT_EXCEEDED, Signal: 9}, "", ""},
+		{"segmentation fault", ExecResult{StatusCode: SEGMENTATION_FAULT_ERROR, Signal: 11}, "", "segmentation fault (invalid memory access or stack overflow)"},
+		{"integer division by zero", ExecResult{StatusCode: RUNTIME_ERROR, Signal: 8}, "", "division by zero"},
+		{"failed assertion", ExecResult{StatusCode: RUNTIME_ERROR, Signal: 6}, "main: main.cpp:3: int main(): Assertion `false' failed.", "aborted (e.g. failed assertion or uncaught exception)"},
+		{"other signal", ExecResult{StatusCode: RUNTIME_ERROR, Signal: 5}, "", "killed by SIGTRAP"},
+		{"java stack overflow", ExecResult{StatusCode: RUNTIME_ERROR, ExitCode: 1}, "Exception in thread \"main\" java.lang.StackOverflowError", "stack overflow"},
+		{"python division by zero", ExecResult{StatusCode: RUNTIME_ERROR, ExitCode: 1}, "ZeroDivisionError: division by zero", "division by zero"},
+		{"python recursion", ExecResult{StatusCode: RUNTIME_ERROR, ExitCode: 1}, "RecursionError: maximum recursion depth exceeded", "stack overflow"},
+		{"go index out of range", ExecResult{StatusCode: RUNTIME_ERROR, ExitCode: 2}, "panic: runtime error: index out of range [5] with length 3", "index out of range"},
+		{"unknown error", ExecResult{StatusCode: RUNTIME_ERROR, ExitCode: 3}, "", "exited with code 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RuntimeErrorReason(tt.result, []byte(tt.stderr)))
+		})
+	}
+}
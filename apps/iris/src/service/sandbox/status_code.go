@@ -11,4 +11,5 @@ const (
 	COMPILE_ERROR
 	SEGMENTATION_FAULT_ERROR
 	SERVER_ERROR
+	OUTPUT_LIMIT_EXCEEDED
 )
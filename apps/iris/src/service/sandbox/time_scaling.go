@@ -0,0 +1,92 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultRealTimeMultiplier is how many times the CPU time limit a run
+	// may take in real time, which leaves room for IO and a busy host.
+	DefaultRealTimeMultiplier = 3
+
+	// The upper bounds keep the limits of a run far from overflowing the int
+	// the sandbox takes them as.
+	MaxScale              = 10
+	MaxRealTimeMultiplier = 10
+	MaxRealTimeGrace      = 60 * 1000
+)
+
+// TimeScaling turns the time limit of a problem into the CPU and real time
+// limits of a run, so that languages with a slow runtime or startup (e.g. the
+// JVM) can be given more time. Zero fields fall back to the defaults.
+type TimeScaling struct {
+	// Scale multiplies the time limit, e.g. 2 for a language twice as slow
+	Scale float64 `yaml:"scale" json:"scale,omitempty"`
+	// RealTimeMultiplier multiplies the scaled time limit into the real time
+	// limit, DefaultRealTimeMultiplier if 0
+	RealTimeMultiplier float64 `yaml:"realTimeMultiplier" json:"realTimeMultiplier,omitempty"`
+	// RealTimeGrace is added to the real time limit in milliseconds
+	RealTimeGrace int `yaml:"realTimeGrace" json:"realTimeGrace,omitempty"`
+}
+
+func (s TimeScaling) Validate() error {
+	switch {
+	case s.Scale < 0:
+		return errors.New("scale must not be less than 0")
+	case s.Scale > MaxScale:
+		return fmt.Errorf("scale must not be greater than %d", MaxScale)
+	case s.RealTimeMultiplier != 0 && s.RealTimeMultiplier < 1:
+		return errors.New("realTimeMultiplier must not be less than 1")
+	case s.RealTimeMultiplier > MaxRealTimeMultiplier:
+		return fmt.Errorf("realTimeMultiplier must not be greater than %d", MaxRealTimeMultiplier)
+	case s.RealTimeGrace < 0:
+		return errors.New("realTimeGrace must not be less than 0")
+	case s.RealTimeGrace > MaxRealTimeGrace:
+		return fmt.Errorf("realTimeGrace must not be greater than %d", MaxRealTimeGrace)
+	}
+	return nil
+}
+
+// TimeLimitScale returns the scale scales gives for language, which either
+// may name by an alias, e.g. "Cpp" for "Cpp14". It is 0 if there is none.
+func TimeLimitScale(scales map[string]float64, language Language) float64 {
+	if scale, ok := scales[string(language)]; ok {
+		return scale
+	}
+	for name, scale := range scales {
+		if Language(name).Resolve() == language.Resolve() {
+			return scale
+		}
+	}
+	return 0
+}
+
+// Override returns s with the fields set in o replacing its own.
+func (s TimeScaling) Override(o TimeScaling) TimeScaling {
+	if o.Scale != 0 {
+		s.Scale = o.Scale
+	}
+	if o.RealTimeMultiplier != 0 {
+		s.RealTimeMultiplier = o.RealTimeMultiplier
+	}
+	if o.RealTimeGrace != 0 {
+		s.RealTimeGrace = o.RealTimeGrace
+	}
+	return s
+}
+
+// Limits returns the CPU and real time limits in milliseconds of a run with
+// the given time limit.
+func (s TimeScaling) Limits(timeLimit int) (cpuTime int, realTime int) {
+	scale, multiplier := s.Scale, s.RealTimeMultiplier
+	if scale == 0 {
+		scale = 1
+	}
+	if multiplier == 0 {
+		multiplier = DefaultRealTimeMultiplier
+	}
+	cpuTime = int(float64(timeLimit)*scale + 0.5)
+	realTime = int(float64(cpuTime)*multiplier+0.5) + s.RealTimeGrace
+	return cpuTime, realTime
+}
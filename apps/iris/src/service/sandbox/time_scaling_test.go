@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeScalingLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		scaling  TimeScaling
+		cpuTime  int
+		realTime int
+	}{
+		{"defaults", TimeScaling{}, 1000, 3000},
+		{"scale", TimeScaling{Scale: 1.5}, 1500, 4500},
+		{"multiplier and grace", TimeScaling{RealTimeMultiplier: 2, RealTimeGrace: 500}, 1000, 2500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuTime, realTime := tt.scaling.Limits(1000)
+			assert.Equal(t, tt.cpuTime, cpuTime)
+			assert.Equal(t, tt.realTime, realTime)
+		})
+	}
+}
+
+func TestTimeScalingOverride(t *testing.T) {
+	language := TimeScaling{Scale: 2, RealTimeGrace: 1000}
+
+	assert.Equal(t, language, language.Override(TimeScaling{}))
+	assert.Equal(t, TimeScaling{Scale: 3, RealTimeMultiplier: 4, RealTimeGrace: 1000}, language.Override(TimeScaling{Scale: 3, RealTimeMultiplier: 4}))
+}
+
+func TestTimeScalingValidate(t *testing.T) {
+	assert.NoError(t, TimeScaling{}.Validate())
+	assert.NoError(t, TimeScaling{Scale: 0.5, RealTimeMultiplier: 1, RealTimeGrace: 100}.Validate())
+	assert.Error(t, TimeScaling{Scale: -1}.Validate())
+	assert.Error(t, TimeScaling{RealTimeMultiplier: 0.5}.Validate())
+	assert.Error(t, TimeScaling{RealTimeGrace: -1}.Validate())
+	assert.Error(t, TimeScaling{Scale: MaxScale + 1}.Validate())
+	assert.Error(t, TimeScaling{RealTimeMultiplier: MaxRealTimeMultiplier + 1}.Validate())
+	assert.Error(t, TimeScaling{RealTimeGrace: MaxRealTimeGrace + 1}.Validate())
+}
+
+func TestTimeLimitScale(t *testing.T) {
+	assert.Equal(t, 2.0, TimeLimitScale(map[string]float64{"Cpp": 2}, CPP))
+	assert.Equal(t, 2.0, TimeLimitScale(map[string]float64{"Cpp14": 2}, CPP), "an alias finds the scale of its version")
+	assert.Equal(t, 2.0, TimeLimitScale(map[string]float64{"Cpp": 2}, CPP14), "a version finds the scale of its alias")
+	assert.Equal(t, 0.0, TimeLimitScale(map[string]float64{"Cpp": 2}, CPP17))
+	assert.Equal(t, 0.0, TimeLimitScale(nil, C))
+}
@@ -0,0 +1,413 @@
+package testcase
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/skkuding/codedang/apps/iris/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// CacheMemoryEnv and CacheDiskEnv bound the size of the testcases kept in
+	// memory and on disk. The disk tier is used only if CacheDirEnv is set.
+	CacheMemoryEnv = "TESTCASE_CACHE_MEMORY_MB"
+	CacheDiskEnv   = "TESTCASE_CACHE_DISK_MB"
+	CacheDirEnv    = "TESTCASE_CACHE_DIR"
+	// CacheValidateIntervalEnv is how long cached testcases are used before
+	// their version is checked against the source again.
+	CacheValidateIntervalEnv = "TESTCASE_CACHE_VALIDATE_INTERVAL_MS"
+
+	DefaultCacheMemoryMB           = 256
+	DefaultCacheDiskMB             = 2048
+	DefaultCacheValidateIntervalMS = 10 * 1000
+
+	cacheFileExt   = ".json"
+	cacheTmpPrefix = ".tmp-"
+)
+
+type CacheConfig struct {
+	MemoryBytes      int64
+	DiskBytes        int64
+	Dir              string
+	ValidateInterval time.Duration
+}
+
+func CacheConfigFromEnv() (CacheConfig, error) {
+	memory, err := utils.GetenvNonNegativeInt(CacheMemoryEnv, DefaultCacheMemoryMB)
+	if err != nil {
+		return CacheConfig{}, err
+	}
+	disk, err := utils.GetenvNonNegativeInt(CacheDiskEnv, DefaultCacheDiskMB)
+	if err != nil {
+		return CacheConfig{}, err
+	}
+	interval, err := utils.GetenvNonNegativeInt(CacheValidateIntervalEnv, DefaultCacheValidateIntervalMS)
+	if err != nil {
+		return CacheConfig{}, err
+	}
+	return CacheConfig{
+		MemoryBytes:      int64(memory) * 1024 * 1024,
+		DiskBytes:        int64(disk) * 1024 * 1024,
+		Dir:              os.Getenv(CacheDirEnv),
+		ValidateInterval: time.Duration(interval) * time.Millisecond,
+	}, nil
+}
+
+// Cache keeps the testcases of recently judged problems in memory and,
+// optionally, on disk, each tier evicting the least recently used problem
+// once it is full. Entries are keyed by problem id and carry the version of
+// the testcases they were loaded at, so that an updated problem is never
+// judged with stale testcases.
+type Cache struct {
+	mu               sync.Mutex
+	memory           *lru
+	disk             *lru
+	dir              string
+	validateInterval time.Duration
+
+	lookups   metric.Int64Counter
+	evictions metric.Int64Counter
+}
+
+type memoryEntry struct {
+	version     string
+	elements    []loader.ElementOut
+	validatedAt time.Time
+}
+
+// diskFile is the content of a cache file.
+type diskFile struct {
+	ProblemId string              `json:"problemId"`
+	Version   string              `json:"version"`
+	Elements  []loader.ElementOut `json:"elements"`
+}
+
+// NewCache returns nil, which caches nothing, if both tiers are disabled.
+// Files left in config.Dir by a previous run are reused.
+func NewCache(config CacheConfig, meter metric.Meter) (*Cache, error) {
+	if config.MemoryBytes == 0 && (config.Dir == "" || config.DiskBytes == 0) {
+		return nil, nil
+	}
+
+	c := &Cache{dir: config.Dir, validateInterval: config.ValidateInterval}
+	c.memory = newLRU(config.MemoryBytes, func(string, any) { c.evicted("memory") })
+	if config.Dir != "" && config.DiskBytes > 0 {
+		c.disk = newLRU(config.DiskBytes, func(_ string, value any) {
+			os.Remove(filepath.Join(c.dir, value.(string)))
+			c.evicted("disk")
+		})
+		if err := c.loadDisk(); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if c.lookups, err = meter.Int64Counter(
+		"testcase.cache.lookups",
+		metric.WithDescription("Testcase cache lookups by result (memory_hit, disk_hit or miss)."),
+	); err != nil {
+		return nil, fmt.Errorf("failed to create cache meter: %w", err)
+	}
+	if c.evictions, err = meter.Int64Counter(
+		"testcase.cache.evictions",
+		metric.WithDescription("Problems evicted from the testcase cache by tier."),
+	); err != nil {
+		return nil, fmt.Errorf("failed to create cache meter: %w", err)
+	}
+	if _, err = meter.Int64ObservableGauge(
+		"testcase.cache.size",
+		metric.WithDescription("Size of the cached testcases by tier."),
+		metric.WithUnit("By"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			o.Observe(c.memory.size, metric.WithAttributes(attribute.String("tier", "memory")))
+			if c.disk != nil {
+				o.Observe(c.disk.size, metric.WithAttributes(attribute.String("tier", "disk")))
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("failed to create cache meter: %w", err)
+	}
+	return c, nil
+}
+
+// loadDisk indexes the cache files in dir, the most recently modified first.
+func (c *Cache) loadDisk() error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache dir: %w", err)
+	}
+
+	type file struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), cacheTmpPrefix) {
+			// 이전 실행이 쓰다 만 파일
+			os.Remove(filepath.Join(c.dir, entry.Name()))
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{entry.Name(), info.Size(), info.ModTime()})
+	}
+	// 오래된 파일부터 넣어야 최근 파일이 LRU의 앞쪽에 남음
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		problemId, ok := problemIdOfFile(f.name)
+		if !ok {
+			continue
+		}
+		if old, ok := c.disk.peek(problemId); ok {
+			os.Remove(filepath.Join(c.dir, old.(string)))
+		}
+		c.disk.add(problemId, f.name, f.size)
+	}
+	return nil
+}
+
+// Fresh returns the testcases of problemId if their version was checked
+// within the validate interval, so that they can be used without asking the
+// source for the current version.
+func (c *Cache) Fresh(problemId string) ([]loader.ElementOut, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.memory.peek(problemId)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*memoryEntry)
+	if time.Since(entry.validatedAt) >= c.validateInterval {
+		return nil, false
+	}
+	c.memory.touch(problemId)
+	c.count("memory_hit")
+	return entry.elements, true
+}
+
+// Get returns the testcases of problemId if they are cached at version.
+func (c *Cache) Get(problemId, version string) ([]loader.ElementOut, bool) {
+	c.mu.Lock()
+	if value, ok := c.memory.get(problemId); ok {
+		entry := value.(*memoryEntry)
+		if entry.version == version {
+			entry.validatedAt = time.Now()
+			c.mu.Unlock()
+			c.count("memory_hit")
+			return entry.elements, true
+		}
+	}
+	var name string
+	if c.disk != nil {
+		if value, ok := c.disk.get(problemId); ok && value.(string) == fileName(problemId, version) {
+			name = value.(string)
+		}
+	}
+	c.mu.Unlock()
+
+	if name != "" {
+		if elements, ok := c.readDisk(name, problemId, version); ok {
+			c.putMemory(problemId, version, elements)
+			c.count("disk_hit")
+			return elements, true
+		}
+	}
+	c.count("miss")
+	return nil, false
+}
+
+// Put caches the testcases of problemId at version. elements must not be
+// modified afterwards.
+func (c *Cache) Put(problemId, version string, elements []loader.ElementOut) {
+	c.putMemory(problemId, version, elements)
+	if c.disk != nil {
+		c.writeDisk(problemId, version, elements)
+	}
+}
+
+func (c *Cache) putMemory(problemId, version string, elements []loader.ElementOut) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.memory.add(problemId, &memoryEntry{
+		version:     version,
+		elements:    elements,
+		validatedAt: time.Now(),
+	}, sizeOf(elements))
+}
+
+func (c *Cache) readDisk(name, problemId, version string) ([]loader.ElementOut, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, false
+	}
+	var file diskFile
+	if err := json.Unmarshal(data, &file); err != nil || file.ProblemId != problemId || file.Version != version {
+		return nil, false
+	}
+	return file.Elements, true
+}
+
+// writeDisk stores the testcases best effort; a problem that cannot be
+// written is only cached in memory.
+func (c *Cache) writeDisk(problemId, version string, elements []loader.ElementOut) {
+	data, err := json.Marshal(diskFile{ProblemId: problemId, Version: version, Elements: elements})
+	if err != nil || int64(len(data)) > c.disk.capacity {
+		return
+	}
+	name := fileName(problemId, version)
+	// 같은 파일을 읽는 중일 수 있으므로 임시 파일에 쓴 뒤 rename
+	tmp, err := os.CreateTemp(c.dir, cacheTmpPrefix+"*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(c.dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.disk.peek(problemId); ok && old.(string) != name {
+		os.Remove(filepath.Join(c.dir, old.(string)))
+	}
+	c.disk.add(problemId, name, int64(len(data)))
+}
+
+func (c *Cache) count(result string) {
+	if c.lookups != nil {
+		c.lookups.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", result)))
+	}
+}
+
+func (c *Cache) evicted(tier string) {
+	if c.evictions != nil {
+		c.evictions.Add(context.Background(), 1, metric.WithAttributes(attribute.String("tier", tier)))
+	}
+}
+
+// fileName is "<problemId>_<version hash>.json". The version is hashed as it
+// may be of any length.
+func fileName(problemId, version string) string {
+	hash := sha256.Sum256([]byte(version))
+	return url.PathEscape(problemId) + "_" + hex.EncodeToString(hash[:8]) + cacheFileExt
+}
+
+func problemIdOfFile(name string) (string, bool) {
+	i := strings.LastIndex(name, "_")
+	if i <= 0 {
+		return "", false
+	}
+	problemId, err := url.PathUnescape(name[:i])
+	return problemId, err == nil
+}
+
+func sizeOf(elements []loader.ElementOut) int64 {
+	var size int64
+	for _, element := range elements {
+		size += int64(len(element.In) + len(element.Out))
+	}
+	return size
+}
+
+// lru holds values up to a total size, dropping the least recently used
+// ones first. A single value larger than the capacity is not held at all.
+type lru struct {
+	capacity int64
+	size     int64
+	order    *list.List // front is the most recently used
+	items    map[string]*list.Element
+	onEvict  func(key string, value any)
+}
+
+type lruItem struct {
+	key   string
+	value any
+	size  int64
+}
+
+func newLRU(capacity int64, onEvict func(key string, value any)) *lru {
+	return &lru{
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+		onEvict:  onEvict,
+	}
+}
+
+func (l *lru) peek(key string) (any, bool) {
+	if element, ok := l.items[key]; ok {
+		return element.Value.(*lruItem).value, true
+	}
+	return nil, false
+}
+
+func (l *lru) get(key string) (any, bool) {
+	value, ok := l.peek(key)
+	if ok {
+		l.touch(key)
+	}
+	return value, ok
+}
+
+func (l *lru) touch(key string) {
+	if element, ok := l.items[key]; ok {
+		l.order.MoveToFront(element)
+	}
+}
+
+// add replaces the value of key without calling onEvict for the old one.
+func (l *lru) add(key string, value any, size int64) {
+	if element, ok := l.items[key]; ok {
+		l.size -= element.Value.(*lruItem).size
+		l.order.Remove(element)
+		delete(l.items, key)
+	}
+	if size > l.capacity {
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruItem{key: key, value: value, size: size})
+	l.size += size
+	for l.size > l.capacity {
+		oldest := l.order.Back()
+		item := oldest.Value.(*lruItem)
+		l.order.Remove(oldest)
+		delete(l.items, item.key)
+		l.size -= item.size
+		l.onEvict(item.key, item.value)
+	}
+}
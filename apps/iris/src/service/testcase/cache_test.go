@@ -0,0 +1,130 @@
+package testcase
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+func newTestCache(t *testing.T, config CacheConfig) *Cache {
+	cache, err := NewCache(config, noop.NewMeterProvider().Meter("test"))
+	require.NoError(t, err)
+	return cache
+}
+
+func elementsOfSize(size int) []loader.ElementOut {
+	return []loader.ElementOut{{Id: 1, In: strings.Repeat("1", size/2), Out: strings.Repeat("2", size-size/2)}}
+}
+
+func TestCache(t *testing.T) {
+	t.Run("hits only the cached version", func(t *testing.T) {
+		cache := newTestCache(t, CacheConfig{MemoryBytes: 100})
+		cache.Put("1", "v1", elementsOfSize(10))
+
+		elements, ok := cache.Get("1", "v1")
+		assert.True(t, ok)
+		assert.Equal(t, elementsOfSize(10), elements)
+
+		_, ok = cache.Get("1", "v2")
+		assert.False(t, ok)
+		_, ok = cache.Get("2", "v1")
+		assert.False(t, ok)
+	})
+
+	t.Run("evicts the least recently used problem", func(t *testing.T) {
+		cache := newTestCache(t, CacheConfig{MemoryBytes: 100})
+		cache.Put("1", "v", elementsOfSize(40))
+		cache.Put("2", "v", elementsOfSize(40))
+		_, ok := cache.Get("1", "v")
+		require.True(t, ok)
+
+		cache.Put("3", "v", elementsOfSize(40))
+		_, ok = cache.Get("2", "v")
+		assert.False(t, ok)
+		_, ok = cache.Get("1", "v")
+		assert.True(t, ok)
+
+		// 용량보다 큰 문제는 캐시하지 않음
+		cache.Put("4", "v", elementsOfSize(200))
+		_, ok = cache.Get("4", "v")
+		assert.False(t, ok)
+		_, ok = cache.Get("3", "v")
+		assert.True(t, ok)
+	})
+
+	t.Run("skips validation within the interval", func(t *testing.T) {
+		cache := newTestCache(t, CacheConfig{MemoryBytes: 100, ValidateInterval: 20 * time.Millisecond})
+		cache.Put("1", "v", elementsOfSize(10))
+
+		_, ok := cache.Fresh("1")
+		assert.True(t, ok)
+		time.Sleep(30 * time.Millisecond)
+		_, ok = cache.Fresh("1")
+		assert.False(t, ok)
+
+		// 버전을 확인하면 다시 fresh
+		_, ok = cache.Get("1", "v")
+		require.True(t, ok)
+		_, ok = cache.Fresh("1")
+		assert.True(t, ok)
+	})
+
+	t.Run("keeps problems on disk across restarts", func(t *testing.T) {
+		dir := t.TempDir()
+		config := CacheConfig{Dir: dir, DiskBytes: 1 << 20}
+		cache := newTestCache(t, config)
+		cache.Put("1", "v1", elementsOfSize(10))
+		cache.Put("1", "v2", elementsOfSize(20))
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+
+		restarted := newTestCache(t, config)
+		_, ok := restarted.Get("1", "v1")
+		assert.False(t, ok)
+		elements, ok := restarted.Get("1", "v2")
+		assert.True(t, ok)
+		assert.Equal(t, elementsOfSize(20), elements)
+	})
+
+	t.Run("evicts files beyond the disk limit", func(t *testing.T) {
+		dir := t.TempDir()
+		cache := newTestCache(t, CacheConfig{Dir: dir, DiskBytes: 300})
+		cache.Put("1", "v", elementsOfSize(100))
+		cache.Put("2", "v", elementsOfSize(100))
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+		_, ok := cache.Get("2", "v")
+		assert.True(t, ok)
+	})
+
+	t.Run("is disabled without any tier", func(t *testing.T) {
+		cache, err := NewCache(CacheConfig{DiskBytes: 1 << 20}, noop.NewMeterProvider().Meter("test"))
+		require.NoError(t, err)
+		assert.Nil(t, cache)
+	})
+}
+
+func TestCacheConfigFromEnv(t *testing.T) {
+	t.Setenv(CacheMemoryEnv, "")
+	t.Setenv(CacheDirEnv, "/tmp/testcases")
+	t.Setenv(CacheValidateIntervalEnv, "0")
+	config, err := CacheConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(DefaultCacheMemoryMB)*1024*1024, config.MemoryBytes)
+	assert.Equal(t, "/tmp/testcases", config.Dir)
+	assert.Zero(t, config.ValidateInterval)
+
+	t.Setenv(CacheDiskEnv, "-1")
+	_, err = CacheConfigFromEnv()
+	assert.EqualError(t, err, "TESTCASE_CACHE_DISK_MB must be a non-negative integer")
+}
@@ -5,6 +5,8 @@ import "github.com/skkuding/codedang/apps/iris/src/loader"
 type Testcase struct {
 	// metadata should be here
 	Elements []loader.ElementOut
+	// Subtasks is empty unless the testcases are scored by subtask
+	Subtasks []Subtask
 }
 
 func (t *Testcase) Count() int {
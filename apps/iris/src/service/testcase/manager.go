@@ -3,12 +3,18 @@ package testcase
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/skkuding/codedang/apps/iris/src/loader"
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 )
 
+const (
+	sourceS3       = "s3"
+	sourceDatabase = "db"
+)
+
 type TestcaseReader interface {
 	GetTestcase(ctx context.Context, problemId string, testcaseFilter TestcaseFilterCode) (Testcase, error)
 }
@@ -25,17 +31,21 @@ type TestcaseManager interface {
 type testcaseManager struct {
 	database *loader.Postgres
 	s3reader *loader.S3reader
+	cache    *Cache
 	logger   logger.Logger
 }
 
+// NewTestcaseManager reads testcases through cache, which may be nil.
 func NewTestcaseManager(
 	s3reader *loader.S3reader,
 	database *loader.Postgres,
+	cache *Cache,
 	logProvider logger.Logger,
 ) TestcaseManager {
 	return &testcaseManager{
 		s3reader: s3reader,
 		database: database,
+		cache:    cache,
 		logger:   logProvider,
 	}
 }
@@ -75,13 +85,14 @@ func (t *testcaseManager) SaveTestcase(ctx context.Context, problemId string, hi
 }
 
 func (t *testcaseManager) GetTestcase(ctx context.Context, problemId string, testcaseFilter TestcaseFilterCode) (Testcase, error) {
-	data, err := t.s3reader.Get(problemId)
+	data, err := t.load(ctx, problemId)
 	if err != nil {
-		data, err = t.database.Get(ctx, problemId)
-		if err != nil {
-			return Testcase{}, fmt.Errorf("GetTestcase: %w", err)
-		}
+		return Testcase{}, fmt.Errorf("GetTestcase: %w", err)
 	}
+	// 캐시에서 꺼낸 testcase의 spool 파일도 채점 중에 지워지지 않도록 표시
+	loader.Touch(data)
+	// 캐시된 slice를 공유하지 않도록 복사
+	data = slices.Clone(data)
 
 	var predicate func(element loader.ElementOut) bool
 
@@ -108,3 +119,57 @@ func (t *testcaseManager) GetTestcase(ctx context.Context, problemId string, tes
 
 	return testcase, nil
 }
+
+// load returns the testcases from the cache as long as their version matches
+// the one of the source. That costs a query for the database, but for S3 a
+// listing plus a tagging request per input, see S3reader.Version.
+func (t *testcaseManager) load(ctx context.Context, problemId string) ([]loader.ElementOut, error) {
+	if t.cache == nil {
+		data, _, err := t.fetch(ctx, problemId)
+		return data, err
+	}
+	if data, ok := t.cache.Fresh(problemId); ok {
+		return data, nil
+	}
+
+	source, version, err := t.version(ctx, problemId)
+	if err != nil {
+		t.logger.Log(logger.WARN, fmt.Sprintf("testcase.cache.bypass problem_id=%s err=%v", problemId, err))
+		data, _, err := t.fetch(ctx, problemId)
+		return data, err
+	}
+	if data, ok := t.cache.Get(problemId, source+":"+version); ok {
+		return data, nil
+	}
+
+	data, fetchedFrom, err := t.fetch(ctx, problemId)
+	if err != nil {
+		return nil, err
+	}
+	// 버전과 다른 저장소에서 읽은 testcase는 캐시하지 않음
+	if fetchedFrom == source {
+		t.cache.Put(problemId, source+":"+version, data)
+	}
+	return data, nil
+}
+
+func (t *testcaseManager) version(ctx context.Context, problemId string) (source, version string, err error) {
+	if version, err := t.s3reader.Version(ctx, problemId); err == nil {
+		return sourceS3, version, nil
+	}
+	if version, err = t.database.Version(ctx, problemId); err != nil {
+		return "", "", err
+	}
+	return sourceDatabase, version, nil
+}
+
+// fetch reads the testcases from S3, falling back to the database.
+func (t *testcaseManager) fetch(ctx context.Context, problemId string) (data []loader.ElementOut, source string, err error) {
+	if data, err = t.s3reader.Get(problemId); err == nil {
+		return data, sourceS3, nil
+	}
+	if data, err = t.database.Get(ctx, problemId); err != nil {
+		return nil, "", err
+	}
+	return data, sourceDatabase, nil
+}
@@ -0,0 +1,30 @@
+package testcase
+
+import "fmt"
+
+// Subtask is worth Score points, awarded only if every testcase whose
+// loader.ElementOut.Subtask equals Id is accepted.
+type Subtask struct {
+	Id    int `json:"id"`
+	Score int `json:"score"`
+}
+
+// SubtaskIndices returns the indices into Elements of each subtask's
+// testcases. Every testcase must belong to a declared subtask, unless there
+// are no subtasks, in which case it returns nil.
+func (t *Testcase) SubtaskIndices() (map[int][]int, error) {
+	if len(t.Subtasks) == 0 {
+		return nil, nil
+	}
+	indices := make(map[int][]int, len(t.Subtasks))
+	for _, subtask := range t.Subtasks {
+		indices[subtask.Id] = []int{}
+	}
+	for i, element := range t.Elements {
+		if _, ok := indices[element.Subtask]; !ok {
+			return nil, fmt.Errorf("testcase %d belongs to undeclared subtask %d", element.Id, element.Subtask)
+		}
+		indices[element.Subtask] = append(indices[element.Subtask], i)
+	}
+	return indices, nil
+}
@@ -0,0 +1,34 @@
+package testcase
+
+import (
+	"testing"
+
+	"github.com/skkuding/codedang/apps/iris/src/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtaskIndices(t *testing.T) {
+	tc := Testcase{
+		Elements: []loader.ElementOut{
+			{Id: 10, Subtask: 0},
+			{Id: 11, Subtask: 2},
+			{Id: 12, Subtask: 1},
+			{Id: 13, Subtask: 2},
+		},
+		Subtasks: []Subtask{{Id: 0}, {Id: 1, Score: 40}, {Id: 2, Score: 60}, {Id: 3, Score: 0}},
+	}
+
+	indices, err := tc.SubtaskIndices()
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]int{0: {0}, 1: {2}, 2: {1, 3}, 3: {}}, indices)
+
+	tc.Subtasks = tc.Subtasks[1:]
+	_, err = tc.SubtaskIndices()
+	assert.EqualError(t, err, "testcase 10 belongs to undeclared subtask 0")
+
+	tc.Subtasks = nil
+	indices, err = tc.SubtaskIndices()
+	require.NoError(t, err)
+	assert.Nil(t, indices)
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/skkuding/codedang/apps/iris/src/service/logger"
 )
@@ -25,3 +26,26 @@ func Getenv(key, fallback string) string {
 	}
 	return value
 }
+
+// GetenvPositiveInt reads key as an integer greater than 0, or returns
+// fallback if it is unset or empty.
+func GetenvPositiveInt(key string, fallback int) (int, error) {
+	return getenvInt(key, fallback, 1, "a positive integer")
+}
+
+// GetenvNonNegativeInt is GetenvPositiveInt allowing 0.
+func GetenvNonNegativeInt(key string, fallback int) (int, error) {
+	return getenvInt(key, fallback, 0, "a non-negative integer")
+}
+
+func getenvInt(key string, fallback int, min int, kind string) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min {
+		return 0, fmt.Errorf("%s must be %s", key, kind)
+	}
+	return value, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/skkuding/codedang/apps/plag/src/router"
 	"github.com/skkuding/codedang/apps/plag/src/service/check"
 	"github.com/skkuding/codedang/apps/plag/src/service/file"
+	"github.com/skkuding/codedang/apps/plag/src/service/health"
 	"github.com/skkuding/codedang/apps/plag/src/service/logger"
 	"github.com/skkuding/codedang/apps/plag/src/utils"
 	"go.opentelemetry.io/otel"
@@ -35,6 +36,11 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
+	// 브로커 연결이 끊긴 동안에는 unhealthy
+	if err := health.Check(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -2,12 +2,14 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	instrumentation "github.com/skkuding/codedang/apps/plag/src"
 	"github.com/skkuding/codedang/apps/plag/src/router"
+	"github.com/skkuding/codedang/apps/plag/src/service/health"
 	"github.com/skkuding/codedang/apps/plag/src/service/logger"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
@@ -30,6 +32,9 @@ func NewConnector(
 	return &connector{consumer, producer, router, make(chan error), logger}
 }
 
+// Connect consumes messages until ctx is done. Whenever the connection or a
+// channel to the broker is lost, it reports unhealthy and reconnects with
+// exponential backoff.
 func (c *connector) Connect(ctx context.Context) {
 	connectorCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer func() {
@@ -38,26 +43,79 @@ func (c *connector) Connect(ctx context.Context) {
 		c.producer.CleanUp()
 	}()
 
-	err := c.consumer.OpenChannel()
+	backoff, err := reconnectBackoffFromEnv()
 	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to open channel: %s", err))
+		c.logger.Log(logger.WARN, fmt.Sprintf("invalid reconnect backoff: %v; using default", err))
+		backoff = time.Duration(DefaultReconnectBackoffMS) * time.Millisecond
 	}
 
-	err = c.producer.OpenChannel()
-	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to open channel: %s", err))
+	health.SetDown(healthComponent, errors.New("not connected yet"))
+	for {
+		err := c.serve(ctx, connectorCtx)
+		if ctx.Err() != nil {
+			break
+		}
+		health.SetDown(healthComponent, err)
+		c.logger.Log(logger.ERROR, fmt.Sprintf("lost connection to broker: %s", err))
+
+		for attempt := 0; ; attempt++ {
+			select {
+			case <-time.After(reconnectDelay(backoff, attempt)):
+			case <-ctx.Done():
+				c.logger.Log(logger.DEBUG, "connector done")
+				return
+			}
+			if err := c.reconnect(); err != nil {
+				c.logger.Log(logger.WARN, fmt.Sprintf("reconnect attempt %d failed: %s", attempt+1, err))
+				continue
+			}
+			c.logger.Log(logger.INFO, "reconnected to broker")
+			break
+		}
 	}
 
+	c.logger.Log(logger.DEBUG, "connector done")
+}
+
+// serve opens the channels and dispatches messages, handled with handleCtx,
+// until the connection to the broker is lost or ctx is done.
+func (c *connector) serve(ctx context.Context, handleCtx context.Context) error {
+	if err := c.consumer.OpenChannel(); err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := c.producer.OpenChannel(); err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
 	messageCh, err := c.consumer.Subscribe()
 	if err != nil {
-		c.logger.Panic(fmt.Sprintf("failed to subscribe: %s", err))
+		return fmt.Errorf("failed to subscribe: %w", err)
 	}
-
-	for message := range messageCh {
-		go c.handle(message, connectorCtx)
+	consumerClosed := c.consumer.NotifyClose()
+	producerClosed := c.producer.NotifyClose()
+	health.SetUp(healthComponent)
+
+	for {
+		select {
+		case message, ok := <-messageCh:
+			if !ok {
+				return errors.New("consumer channel closed")
+			}
+			go c.handle(message, handleCtx)
+		case err := <-consumerClosed:
+			return fmt.Errorf("consumer closed: %v", err)
+		case err := <-producerClosed:
+			return fmt.Errorf("producer closed: %v", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+}
 
-	c.logger.Log(logger.DEBUG, "connector done")
+func (c *connector) reconnect() error {
+	if err := c.consumer.Reconnect(); err != nil {
+		return err
+	}
+	return c.producer.Reconnect()
 }
 
 func (c *connector) Disconnect() {}
@@ -1,9 +1,7 @@
 package rabbitmq
 
 import (
-	"crypto/tls"
 	"fmt"
-	"os"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/skkuding/codedang/apps/plag/src/service/logger"
@@ -12,17 +10,22 @@ import (
 type Consumer interface {
 	OpenChannel() error
 	Subscribe() (<-chan amqp.Delivery, error)
+	// NotifyClose must be called after OpenChannel.
+	NotifyClose() <-chan *amqp.Error
+	Reconnect() error
 	CleanUp() error
 	// Ack(channelName string, tag uint64) error
 }
 
 type consumer struct {
-	connection *amqp.Connection
-	channel    *amqp.Channel
-	queueName  string
-	tag        string
-	Done       chan error
-	logger     logger.Logger
+	connection     *amqp.Connection
+	channel        *amqp.Channel
+	uri            string
+	connectionName string
+	queueName      string
+	tag            string
+	Done           chan error
+	logger         logger.Logger
 }
 
 type ConsumerConfig struct {
@@ -33,27 +36,20 @@ type ConsumerConfig struct {
 }
 
 func NewConsumer(config ConsumerConfig, logger logger.Logger) (*consumer, error) {
-
-	// Create New RabbitMQ Connection (go <-> RabbitMQ)
-	amqpConfig := amqp.Config{
-		Properties: amqp.NewConnectionProperties(),
-	}
-	if os.Getenv("RABBITMQ_SSL") == "true" {
-		amqpConfig.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-	amqpConfig.Properties.SetClientConnectionName(config.ConnectionName)
-	connection, err := amqp.DialConfig(config.AmqpURI, amqpConfig)
+	connection, err := dial(config.AmqpURI, config.ConnectionName)
 	if err != nil {
 		return nil, fmt.Errorf("consumer: dial failed: %w", err)
 	}
 
 	return &consumer{
-		connection: connection,
-		channel:    nil,
-		queueName:  config.QueueName,
-		tag:        config.Ctag,
-		Done:       make(chan error),
-		logger:     logger,
+		connection:     connection,
+		channel:        nil,
+		uri:            config.AmqpURI,
+		connectionName: config.ConnectionName,
+		queueName:      config.QueueName,
+		tag:            config.Ctag,
+		Done:           make(chan error),
+		logger:         logger,
 	}, nil
 }
 
@@ -93,6 +89,21 @@ func (c *consumer) Subscribe() (<-chan amqp.Delivery, error) {
 	return messages, nil
 }
 
+func (c *consumer) NotifyClose() <-chan *amqp.Error {
+	return notifyClose(c.connection, c.channel)
+}
+
+// Reconnect replaces the connection. The channel has to be opened and
+// subscribed again afterwards.
+func (c *consumer) Reconnect() error {
+	connection, err := redial(c.connection, c.uri, c.connectionName)
+	if err != nil {
+		return fmt.Errorf("consumer: dial failed: %w", err)
+	}
+	c.connection = connection
+	return nil
+}
+
 func (c *consumer) CleanUp() error {
 
 	c.logger.Log(logger.DEBUG, "consumer clean up")
@@ -2,10 +2,9 @@ package rabbitmq
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"net/http"
-	"os"
+	"sync"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	instrumentation "github.com/skkuding/codedang/apps/plag/src"
@@ -18,17 +17,25 @@ import (
 type Producer interface {
 	OpenChannel() error
 	Publish([]byte, context.Context, string) error
+	// NotifyClose must be called after OpenChannel.
+	NotifyClose() <-chan *amqp.Error
+	Reconnect() error
 	CleanUp() error
 }
 
 type producer struct {
-	connection   *amqp.Connection
-	channel      *amqp.Channel
-	exchangeName string
-	routingKey   string
-	Done         chan error
-	publishes    chan uint64
-	logger       logger.Logger
+	// mu guards connection and channel, which are replaced on reconnect while
+	// results are being published.
+	mu             sync.RWMutex
+	connection     *amqp.Connection
+	channel        *amqp.Channel
+	uri            string
+	connectionName string
+	exchangeName   string
+	routingKey     string
+	Done           chan error
+	publishes      chan uint64
+	logger         logger.Logger
 }
 
 type ProducerConfig struct {
@@ -39,34 +46,29 @@ type ProducerConfig struct {
 }
 
 func NewProducer(config ProducerConfig, logger logger.Logger) (*producer, error) {
-
-	// Create New RabbitMQ Connection (go <-> RabbitMQ)
-	amqpConfig := amqp.Config{
-		Properties: amqp.NewConnectionProperties(),
-	}
-	if os.Getenv("RABBITMQ_SSL") == "true" {
-		amqpConfig.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-	amqpConfig.Properties.SetClientConnectionName(config.ConnectionName)
-	connection, err := amqp.DialConfig(config.AmqpURI, amqpConfig)
+	connection, err := dial(config.AmqpURI, config.ConnectionName)
 	if err != nil {
 		return nil, fmt.Errorf("consumer: dial failed: %w", err)
 	}
 
 	return &producer{
-		connection:   connection,
-		channel:      nil,
-		exchangeName: config.ExchangeName,
-		routingKey:   config.RoutingKey,
-		Done:         make(chan error),
-		publishes:    make(chan uint64, 8),
-		logger:       logger,
+		connection:     connection,
+		channel:        nil,
+		uri:            config.AmqpURI,
+		connectionName: config.ConnectionName,
+		exchangeName:   config.ExchangeName,
+		routingKey:     config.RoutingKey,
+		Done:           make(chan error),
+		publishes:      make(chan uint64, 8),
+		logger:         logger,
 	}, nil
 }
 
 func (p *producer) OpenChannel() error {
-	var err error
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
+	var err error
 	if p.channel, err = p.connection.Channel(); err != nil {
 		return fmt.Errorf("channel: %s", err)
 	}
@@ -94,7 +96,12 @@ func (p *producer) confirmHandler(confirms chan amqp.Confirmation) {
 		case publishSeqNo := <-p.publishes:
 			// log.Printf("waiting for confirmation of %d", publishSeqNo)
 			m[publishSeqNo] = false
-		case confirmed := <-confirms:
+		case confirmed, ok := <-confirms:
+			if !ok {
+				// 채널이 닫히면 재연결 시 새 핸들러가 시작됨
+				p.logger.Log(logger.DEBUG, "confirmHandler is stopping: channel closed")
+				return
+			}
 			p.logger.Log(logger.DEBUG, fmt.Sprintf("tag : %d", confirmed.DeliveryTag))
 			if confirmed.DeliveryTag > 0 {
 				if confirmed.Ack {
@@ -121,7 +128,6 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 	)
 	defer childSpan.End()
 
-	seqNo := p.channel.GetNextPublishSeqNo()
 	p.logger.Log(logger.INFO, fmt.Sprintf("publishing %dB body", len(result)))
 	p.logger.Log(logger.INFO, string(result))
 
@@ -130,8 +136,10 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 
 	headers := convertHeaderCarrierToTable(carrier)
 
+	p.mu.RLock()
+	seqNo := p.channel.GetNextPublishSeqNo()
 	// https://www.rabbitmq.com/publishers.html
-	if err := p.channel.PublishWithContext(spanCtx,
+	err := p.channel.PublishWithContext(spanCtx,
 		p.exchangeName, // publish to an exchange
 		p.routingKey,   // routing to 0 or more queues
 		false,          // mandatory
@@ -145,15 +153,39 @@ func (p *producer) Publish(result []byte, ctx context.Context, messageType strin
 			Priority:        0,
 			Type:            messageType, // 0-9
 		},
-	); err != nil {
+	)
+	p.mu.RUnlock()
+	if err != nil {
 		return fmt.Errorf("exchange publish: %s", err)
 	}
 
 	p.logger.Log(logger.DEBUG, fmt.Sprintf("published %dB OK", len(result)))
+	// 채널이 닫혀 confirmHandler가 멈춘 동안에도 재연결이 잠금을 얻을 수 있도록
+	// 잠금을 푼 뒤 넘김. 새 채널의 confirmHandler가 이어받음
 	p.publishes <- seqNo
 	return nil
 }
 
+func (p *producer) NotifyClose() <-chan *amqp.Error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return notifyClose(p.connection, p.channel)
+}
+
+// Reconnect replaces the connection. The channel has to be opened again
+// afterwards.
+func (p *producer) Reconnect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	connection, err := redial(p.connection, p.uri, p.connectionName)
+	if err != nil {
+		return fmt.Errorf("producer: dial failed: %w", err)
+	}
+	p.connection = connection
+	return nil
+}
+
 func (p *producer) CleanUp() error {
 	if err := p.channel.Close(); err != nil {
 		return fmt.Errorf("channel close failed: %s", err)
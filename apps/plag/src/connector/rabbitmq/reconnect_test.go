@@ -0,0 +1,36 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	assert.Equal(t, time.Second, reconnectDelay(time.Second, 0))
+	assert.Equal(t, 4*time.Second, reconnectDelay(time.Second, 2))
+	assert.Equal(t, maxReconnectBackoff, reconnectDelay(time.Second, 10))
+}
+
+func TestReconnectBackoffFromEnv(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Setenv(ReconnectBackoffEnv, "")
+		backoff, err := reconnectBackoffFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(DefaultReconnectBackoffMS)*time.Millisecond, backoff)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv(ReconnectBackoffEnv, "250")
+		backoff, err := reconnectBackoffFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, 250*time.Millisecond, backoff)
+	})
+
+	t.Run("rejects non-positive values", func(t *testing.T) {
+		t.Setenv(ReconnectBackoffEnv, "0")
+		_, err := reconnectBackoffFromEnv()
+		assert.EqualError(t, err, "RABBITMQ_RECONNECT_BACKOFF_MS must be a positive integer")
+	})
+}
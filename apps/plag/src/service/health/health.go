@@ -0,0 +1,45 @@
+// Package health tracks whether the components the service depends on, such
+// as the broker connection, are currently up.
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	mu   sync.RWMutex
+	down = map[string]error{}
+)
+
+// SetDown marks component as unavailable because of err.
+func SetDown(component string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	down[component] = err
+}
+
+func SetUp(component string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(down, component)
+}
+
+// Check returns nil if every component is up, otherwise an error naming the
+// components that are down.
+func Check() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(down) == 0 {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(down))
+	for component, err := range down {
+		reasons = append(reasons, fmt.Sprintf("%s: %v", component, err))
+	}
+	sort.Strings(reasons)
+	return fmt.Errorf("unhealthy: %s", strings.Join(reasons, "; "))
+}
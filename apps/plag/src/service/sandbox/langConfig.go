@@ -2,8 +2,23 @@ package sandbox
 
 type Language string
 
-func (l Language) GetLangExt() string {
+// Base returns the language a versioned identifier of iris (e.g. Cpp17 or
+// Python3.11) belongs to, or l itself, so that all versions of a language
+// are checked against each other.
+func (l Language) Base() Language {
 	switch l {
+	case CPP14, CPP17, CPP20:
+		return CPP
+	case JAVA17, JAVA21:
+		return JAVA
+	case PYTHON311, PYTHON312:
+		return PYTHON
+	}
+	return l
+}
+
+func (l Language) GetLangExt() string {
+	switch l.Base() {
 	case C:
 		return "c"
 	case CPP:
@@ -12,13 +27,21 @@ func (l Language) GetLangExt() string {
 		return "java"
 	case PYPY, PYTHON:
 		return "py"
+	case GO:
+		return "go"
+	case RUST:
+		return "rs"
+	case KOTLIN:
+		return "kt"
+	case JAVASCRIPT:
+		return "js"
 	default:
 		return ""
 	}
 }
 
 func (l Language) GetLangArg() string {
-	switch l {
+	switch l.Base() {
 	case C:
 		return "c"
 	case CPP:
@@ -27,23 +50,42 @@ func (l Language) GetLangArg() string {
 		return "java"
 	case PYPY, PYTHON:
 		return "python3"
+	case GO:
+		return "go"
+	case RUST:
+		return "rust"
+	case KOTLIN:
+		return "kotlin"
+	case JAVASCRIPT:
+		return "javascript"
 	default:
 		return ""
 	}
 }
 
 func (l Language) IsValid() bool {
-	switch l {
-	case "C", "Cpp", "Java", "Python3", "PyPy3":
+	switch l.Base() {
+	case "C", "Cpp", "Java", "Python3", "PyPy3", "Golang", "Rust", "Kotlin", "JavaScript":
 		return true
 	}
 	return false
 }
 
 const (
-	C      Language = "C"
-	CPP    Language = "Cpp"
-	JAVA   Language = "Java"
-	PYTHON Language = "Python3"
-	PYPY   Language = "PyPy3"
+	C          Language = "C"
+	CPP        Language = "Cpp"
+	CPP14      Language = "Cpp14"
+	CPP17      Language = "Cpp17"
+	CPP20      Language = "Cpp20"
+	JAVA       Language = "Java"
+	JAVA17     Language = "Java17"
+	JAVA21     Language = "Java21"
+	PYTHON     Language = "Python3"
+	PYTHON311  Language = "Python3.11"
+	PYTHON312  Language = "Python3.12"
+	PYPY       Language = "PyPy3"
+	GO         Language = "Golang"
+	RUST       Language = "Rust"
+	KOTLIN     Language = "Kotlin"
+	JAVASCRIPT Language = "JavaScript"
 )